@@ -19,6 +19,50 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/cache/invalidate": {
+            "post": {
+                "description": "Webhook for change notifications, evicts cached entities and permissions. Only available if a cache invalidation secret is configured, which has to be sent in the X-Cache-Invalidation-Secret header.\nKinds are device, device_group, location, service, function, concept, device_type and export.\nSingle instance only: entries are evicted from memcached or Redis for all instances, but the in-process caches (L1, permissions, memory backend) are only cleared on the instance receiving the request. With multiple instances, publish the changes to the cache invalidation topics instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "invalidate cache",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "configured secret",
+                        "name": "X-Cache-Invalidation-Secret",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "changed entities",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/invalidation.Event"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
         "/data-availability": {
             "get": {
                 "security": [
@@ -65,8 +109,14 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
@@ -78,7 +128,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "download CSV",
+                "description": "download CSV or Parquet file",
                 "consumes": [
                     "application/json"
                 ],
@@ -89,7 +139,7 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "JSON encoded QueriesRequestElement",
+                        "description": "JSON encoded QueriesRequestElement. Device group and location requests are written in long format with the columns time, device_id, service_id, column and value",
                         "name": "query",
                         "in": "query",
                         "required": true
@@ -99,6 +149,12 @@ const docTemplate = `{
                         "description": "Textual representation of the date 'Mon Jan 2 15:04:05 -0700 MST 2006'. Example: 2006-01-02T15:04:05.000Z07:00 would format timestamps as rfc3339 with ms precision. Find details here: https://golang.org/pkg/time/#Time.Format",
                         "name": "time_format",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "file format, csv (default) or parquet. Parquet files use the column types of the database and ignore time_format",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -120,15 +176,21 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
         },
         "/download/{secret}": {
             "get": {
-                "description": "downloads CSV file with previously prepared secret",
+                "description": "downloads CSV or Parquet file with previously prepared secret",
                 "produces": [
                     "text/plain"
                 ],
@@ -167,6 +229,46 @@ const docTemplate = `{
                 }
             }
         },
+        "/health/live": {
+            "get": {
+                "description": "answers as long as the service is running",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "liveness",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "description": "checks the database and cache backend, and the permissions and device-repository services if health_check_upstreams is set",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "readiness",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/last-message": {
             "get": {
                 "security": [
@@ -213,8 +315,14 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
@@ -275,8 +383,28 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "metrics in the Prometheus text format",
+                "produces": [
+                    "text/plain"
+                ],
+                "summary": "metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK"
                     }
                 }
             }
@@ -299,7 +427,7 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "JSON encoded QueriesRequestElement",
+                        "description": "JSON encoded QueriesRequestElement. Device group and location requests are written in long format with the columns time, device_id, service_id, column and value",
                         "name": "query",
                         "in": "query",
                         "required": true
@@ -309,6 +437,12 @@ const docTemplate = `{
                         "description": "Textual representation of the date 'Mon Jan 2 15:04:05 -0700 MST 2006'. Example: 2006-01-02T15:04:05.000Z07:00 would format timestamps as rfc3339 with ms precision. Find details here: https://golang.org/pkg/time/#Time.Format",
                         "name": "time_format",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "file format, csv (default) or parquet. Parquet files use the column types of the database and ignore time_format",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -330,8 +464,71 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
+                    }
+                }
+            }
+        },
+        "/purge": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes the data of a device, of one device service or of an export, either in the time range start \u003c= time \u003c end or entirely.\nContinuous aggregates of the tables are dropped, or refreshed for time ranges. Materialized views are refreshed. Cached last values are removed.\nRequires administrate permission, which only owners have.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Purge data",
+                "parameters": [
+                    {
+                        "description": "data to delete",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PurgeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PurgeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
@@ -366,7 +563,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "specifies output format. Use per_query (default) for a 3D array or table for a 2D array with merged timestamps",
+                        "description": "specifies output format. Use per_query (default) for a 3D array, table for a 2D array with merged timestamps or arrow for the table as Arrow IPC stream with column types of the database",
                         "name": "format",
                         "in": "query"
                     },
@@ -409,8 +606,14 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
@@ -444,7 +647,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "specifies output format. Use per_query (default) for a 3D array or table for a 2D array with merged timestamps",
+                        "description": "specifies output format. Use arrow for an Arrow IPC stream with one row per value and the columns request_index, device_id, service_id, export_id, column, time and value. Column types are taken from the database. Can't be combined with stream. JSON is returned otherwise.",
                         "name": "format",
                         "in": "query"
                     },
@@ -483,6 +686,18 @@ const docTemplate = `{
                         "description": "Calculate aggregations with the specified timezone instead of the default device timezone. Might increase calculation complexity and response time.",
                         "name": "force_tz",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Write each response element as soon as its queries finish and read rows from the database incrementally. Intended for large results. Errors after the first byte was written truncate the JSON array and are reported in the X-Stream-Error trailer.",
+                        "name": "stream",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Do not execute the queries. Instead, returns an array of model.QueriesV2DryRunResponseElement with the generated SQL, the selected table or continuous aggregate and the EXPLAIN cost estimate of each query. Bypasses the last-values cache.",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -507,8 +722,14 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
@@ -585,8 +806,181 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
+                    }
+                }
+            }
+        },
+        "/rows": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Works like inserting rows, but existing rows with the same time are replaced. Requires write permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Upsert rows",
+                "parameters": [
+                    {
+                        "description": "rows",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WriteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.WriteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Inserts rows into the table of a device service or an export. Each row starts with the time as RFC3339 string, followed by one value per column. Requires write permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Insert rows",
+                "parameters": [
+                    {
+                        "description": "rows",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WriteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.WriteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes all rows of a device service or an export with start \u003c= time \u003c end. Requires write permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete rows",
+                "parameters": [
+                    {
+                        "description": "time range",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.DeleteRowsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.WriteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
@@ -641,8 +1035,14 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
@@ -697,8 +1097,14 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
                     }
                 }
             }
@@ -714,9 +1120,61 @@ const docTemplate = `{
                 "value": {
                     "type": "object",
                     "additionalProperties": true
+                },
+                "written_back": {
+                    "description": "WrittenBack marks entries this service read from the database, components receiving device messages overwrite them",
+                    "type": "boolean"
                 }
             }
         },
+        "invalidation.Event": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/invalidation.Kind"
+                }
+            }
+        },
+        "invalidation.Kind": {
+            "type": "string",
+            "enum": [
+                "device",
+                "device_group",
+                "location",
+                "service",
+                "function",
+                "concept",
+                "device_type",
+                "export"
+            ],
+            "x-enum-comments": {
+                "DeviceType": "device types are not cached, but change selectables and their services",
+                "Export": "last messages and permissions of exports are cached"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "",
+                "",
+                "",
+                "",
+                "device types are not cached, but change selectables and their services",
+                "last messages and permissions of exports are cached"
+            ],
+            "x-enum-varnames": [
+                "Device",
+                "DeviceGroup",
+                "Location",
+                "Service",
+                "Function",
+                "Concept",
+                "DeviceType",
+                "Export"
+            ]
+        },
         "model.DataAvailabilityResponseElement": {
             "type": "object",
             "properties": {
@@ -737,6 +1195,26 @@ const docTemplate = `{
                 }
             }
         },
+        "model.DeleteRowsRequest": {
+            "type": "object",
+            "properties": {
+                "deviceId": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "exportId": {
+                    "type": "string"
+                },
+                "serviceId": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
         "model.Direction": {
             "type": "string",
             "enum": [
@@ -748,6 +1226,42 @@ const docTemplate = `{
                 "Desc"
             ]
         },
+        "model.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.HealthStatus"
+                }
+            }
+        },
+        "model.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/model.HealthCheck"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/model.HealthStatus"
+                }
+            }
+        },
+        "model.HealthStatus": {
+            "type": "string",
+            "enum": [
+                "ok",
+                "error"
+            ],
+            "x-enum-varnames": [
+                "HealthOk",
+                "HealthError"
+            ]
+        },
         "model.LastValuesRequestElement": {
             "type": "object",
             "properties": {
@@ -786,6 +1300,62 @@ const docTemplate = `{
                 "value": {}
             }
         },
+        "model.PurgeRequest": {
+            "type": "object",
+            "properties": {
+                "deviceId": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "exportId": {
+                    "type": "string"
+                },
+                "serviceId": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PurgeResponse": {
+            "type": "object",
+            "properties": {
+                "continuousAggregates": {
+                    "description": "dropped, or refreshed for time ranges",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "deletedRows": {
+                    "description": "only counted for time ranges, tables are truncated otherwise",
+                    "type": "integer"
+                },
+                "materializedViews": {
+                    "description": "refreshed",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "serviceIds": {
+                    "description": "services of purged device tables",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "model.QueriesRequestElement": {
             "type": "object",
             "properties": {
@@ -810,6 +1380,12 @@ const docTemplate = `{
                         "$ref": "#/definitions/model.QueriesRequestElementFilter"
                     }
                 },
+                "groupOffset": {
+                    "type": "string"
+                },
+                "groupOrigin": {
+                    "type": "string"
+                },
                 "groupTime": {
                     "type": "string"
                 },
@@ -839,9 +1415,18 @@ const docTemplate = `{
                 "conceptId": {
                     "type": "string"
                 },
+                "counterMax": {
+                    "type": "number"
+                },
+                "counterReset": {
+                    "type": "boolean"
+                },
                 "criteria": {
                     "$ref": "#/definitions/models.DeviceGroupFilterCriteria"
                 },
+                "fill": {
+                    "type": "string"
+                },
                 "groupType": {
                     "type": "string"
                 },
@@ -944,6 +1529,41 @@ const docTemplate = `{
                 }
             }
         },
+        "model.WriteRequest": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "deviceId": {
+                    "type": "string"
+                },
+                "exportId": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {}
+                    }
+                },
+                "serviceId": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.WriteResponse": {
+            "type": "object",
+            "properties": {
+                "rowsAffected": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.DeviceGroupFilterCriteria": {
             "type": "object",
             "properties": {
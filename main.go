@@ -48,10 +48,13 @@ func main() {
 
 	go func() {
 		shutdown := make(chan os.Signal, 1)
-		signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL)
+		signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM) // SIGKILL can't be caught
 		sig := <-shutdown
 		_log.Logger.Info("received shutdown signal", "signal", sig)
 		cancel()
+		sig = <-shutdown
+		_log.Logger.Warn("received second shutdown signal, exiting without draining", "signal", sig)
+		os.Exit(1)
 	}()
 
 	wg.Wait()
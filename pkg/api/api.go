@@ -19,11 +19,14 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"reflect"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SENERGY-Platform/converter/lib/converter"
@@ -32,25 +35,50 @@ import (
 	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/limits"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
 	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 )
 
+// apiWriteTimeout limits the time to write a response of the authenticated api
+const apiWriteTimeout = 30 * time.Second
+
+// unauthenticatedApiWriteTimeout limits the time to write a response of the unauthenticated api
+const unauthenticatedApiWriteTimeout = 30 * time.Minute
+
+// defaultShutdownTimeout limits the time to drain requests on shutdown if no shutdown_timeout is configured
+const defaultShutdownTimeout = 30 * time.Second
+
 var endpoints = []func(router gin.IRouter, config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, cache *cache.RemoteCache, converter *converter.Converter, deviceSelection deviceSelection.Client){}
 var unauthenticatedEndpoints = []func(router gin.IRouter, config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, cache *cache.RemoteCache, converter *converter.Converter, deviceSelection deviceSelection.Client){}
 
+// shuttingDown turns readiness false as soon as the shutdown starts
+var shuttingDown atomic.Bool
+
+// Start starts the api servers. When ctx is done, readiness turns false, and after the shutdown delay both servers stop
+// accepting requests and drain. Requests still running after the shutdown timeout are cancelled.
+// wg is done when all requests are done.
 func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, cache *cache.RemoteCache, converter *converter.Converter, deviceSelection deviceSelection.Client) (err error) {
 	log.Logger.Info("start api")
+	shutdownDelay, shutdownTimeout, err := shutdownDurations(config)
+	if err != nil {
+		return err
+	}
 	http.DefaultClient.Timeout = 10 * time.Second
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	baseContext := func(net.Listener) context.Context { return requestCtx }
+	requests := &sync.WaitGroup{}
 	router := Router(config, wrapper, verifier, cache, converter, deviceSelection)
-	server := &http.Server{Addr: ":" + config.ApiPort, Handler: router, WriteTimeout: 30 * time.Second, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second}
+	server := &http.Server{Addr: ":" + config.ApiPort, Handler: trackRequests(requests, router), WriteTimeout: apiWriteTimeout, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second, BaseContext: baseContext}
 	unauthenticatedRouter := UnauthenticatedRouter(config, wrapper, verifier, cache, converter, deviceSelection)
-	unauthenticatedServer := &http.Server{Addr: ":" + config.UnauthenticatedApiPort, Handler: unauthenticatedRouter, WriteTimeout: 30 * time.Minute, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second}
+	unauthenticatedServer := &http.Server{Addr: ":" + config.UnauthenticatedApiPort, Handler: trackRequests(requests, unauthenticatedRouter), WriteTimeout: unauthenticatedApiWriteTimeout, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second, BaseContext: baseContext}
 	wg.Add(1)
 	go func() {
 		log.Logger.Info("Listening on " + server.Addr)
@@ -68,16 +96,61 @@ func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config,
 		}
 	}()
 	go func() {
+		defer wg.Done()
 		<-ctx.Done()
-		log.Logger.Debug("api shutdown")
-		server.Shutdown(context.Background())
-		log.Logger.Debug("unauthenticated api shutdown")
-		unauthenticatedServer.Shutdown(context.Background())
-		wg.Done()
+		shuttingDown.Store(true)
+		log.Logger.Info("api shutdown, waiting " + shutdownDelay.String() + " before draining requests")
+		time.Sleep(shutdownDelay) // lets load balancers notice the failing readiness
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		drained := &sync.WaitGroup{}
+		for _, s := range []*http.Server{server, unauthenticatedServer} {
+			drained.Add(1)
+			go func() {
+				defer drained.Done()
+				err := s.Shutdown(shutdownCtx)
+				if err != nil {
+					// cancelled requests stop their queries, streaming responses end with the error trailer
+					log.Logger.Warn("api server "+s.Addr+" did not drain in time, cancelling remaining requests", attributes.ErrorKey, err)
+					cancelRequests()
+					_ = s.Close()
+				}
+			}()
+		}
+		drained.Wait()
+		requests.Wait()
+		cancelRequests()
+		log.Logger.Info("api shutdown complete")
 	}()
 	return nil
 }
 
+// trackRequests adds running requests to wg, so the shutdown can wait for handlers which outlive their connection
+func trackRequests(wg *sync.WaitGroup, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wg.Add(1)
+		defer wg.Done()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func shutdownDurations(config configuration.Config) (delay time.Duration, timeout time.Duration, err error) {
+	timeout = defaultShutdownTimeout
+	if config.ShutdownDelay != "" {
+		delay, err = time.ParseDuration(config.ShutdownDelay)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid shutdown_delay: %w", err)
+		}
+	}
+	if config.ShutdownTimeout != "" {
+		timeout, err = time.ParseDuration(config.ShutdownTimeout)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid shutdown_timeout: %w", err)
+		}
+	}
+	return delay, timeout, nil
+}
+
 // GetRouter doc
 // @title         Timescale Wrapper API
 // @version       0.1
@@ -92,6 +165,15 @@ func Router(config configuration.Config, wrapper *timescale.Wrapper, verifier *v
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	configureMW(router)
+	if config.RequestTimeout != "" {
+		timeout, err := time.ParseDuration(config.RequestTimeout)
+		if err != nil {
+			log.Logger.Error("invalid request_timeout, requests will not time out", attributes.ErrorKey, err)
+		} else {
+			router.Use(requestTimeout(timeout))
+		}
+	}
+	router.Use(rateLimit(config, limits.New(config), cache))
 	for _, e := range endpoints {
 		log.Logger.Info("add endpoints: " + runtime.FuncForPC(reflect.ValueOf(e).Pointer()).Name())
 		e(router, config, wrapper, verifier, cache, converter, deviceSelection)
@@ -119,11 +201,24 @@ func configureMW(router *gin.Engine) {
 			nil,
 		),
 		requestid.New(requestid.WithCustomHeaderStrKey("X-Request-ID")),
+		tracing.Middleware(),
+		metrics.Middleware(),
 		gin_mw.ErrorHandler(model.GetStatusCode, ", "),
 		gin_mw.StructRecoveryHandler(log.Logger, gin_mw.DefaultRecoveryFunc),
 	)
 }
 
+// requestTimeout sets a deadline on the request context. Running queries and upstream calls of the request are cancelled
+// when it expires, the same way they are cancelled when the client disconnects.
+func requestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
 func getToken(request *http.Request) string {
 	return request.Header.Get("Authorization")
 }
@@ -0,0 +1,259 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/converter/lib/converter"
+	deviceSelection "github.com/SENERGY-Platform/device-selection/pkg/client"
+	dsmodel "github.com/SENERGY-Platform/device-selection/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
+	"github.com/golang-jwt/jwt"
+)
+
+func TestStartShutdown(t *testing.T) {
+	log.InitForTest()
+	t.Cleanup(func() { shuttingDown.Store(false) })
+	config := &configuration.ConfigStruct{
+		ApiPort:                freePort(t),
+		UnauthenticatedApiPort: freePort(t),
+		ShutdownDelay:          "500ms",
+		ShutdownTimeout:        "5s",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	err := Start(ctx, wg, config, nil, nil, cache.NewRemoteWithBackend(config, cache.NewMemoryBackend(0), nil, nil), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unauthenticatedUrl := "http://localhost:" + config.UnauthenticatedApiPort
+	for i := 0; ; i++ {
+		resp, err := http.Get(unauthenticatedUrl + "/health/live")
+		if err == nil {
+			_ = resp.Body.Close()
+			break
+		}
+		if i > 100 {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	// within the shutdown delay the server still answers, but is not ready
+	resp, err := http.Get(unauthenticatedUrl + "/health/ready")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var health model.HealthResponse
+	err = json.NewDecoder(resp.Body).Decode(&health)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || health.Checks["shutdown"].Status != model.HealthError {
+		t.Error("expected not ready while shutting down", resp.StatusCode, health)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not complete")
+	}
+	_, err = http.Get(unauthenticatedUrl + "/health/live")
+	if err == nil {
+		t.Error("expected server to be stopped")
+	}
+}
+
+func TestShutdownDurations(t *testing.T) {
+	delay, timeout, err := shutdownDurations(&configuration.ConfigStruct{})
+	if err != nil || delay != 0 || timeout != defaultShutdownTimeout {
+		t.Error("unexpected defaults", delay, timeout, err)
+	}
+	_, _, err = shutdownDurations(&configuration.ConfigStruct{ShutdownTimeout: "soon"})
+	if err == nil {
+		t.Error("expected error for invalid shutdown_timeout")
+	}
+}
+
+// testServer runs the routers with fake permissions and an in-memory cache. No database is available,
+// so requests are either answered from the cache or fail before a query is executed.
+type testServer struct {
+	backend            cache.Backend
+	cache              *cache.RemoteCache
+	verifier           *verification.Verifier
+	url                string
+	unauthenticatedUrl string
+	token              string
+}
+
+const testDeviceId = "urn:infai:ses:device:ade1fba6-fa5f-4704-9997-81dc168f62f4"
+const testServiceId = "urn:infai:ses:service:97805820-ca0a-46c5-9dcf-16c2e386b050"
+
+// newTestServer starts the routers with config. Permission checks of ids containing "denied" fail, those containing "slow" block until the test ends.
+func newTestServer(t *testing.T, config *configuration.ConfigStruct) *testServer {
+	log.InitForTest()
+	release := make(chan struct{})
+	permissions := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "slow") {
+			<-release
+		}
+		_ = json.NewEncoder(w).Encode(!strings.Contains(r.URL.Path, "denied"))
+	}))
+	t.Cleanup(permissions.Close)
+	t.Cleanup(func() { close(release) })
+	selection := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(selection.Close)
+	config.PermissionsUrl = permissions.URL
+	config.DeviceSelectionUrl = selection.URL
+	config.DefaultTimezone = "Europe/Berlin"
+
+	backend := cache.NewMemoryBackend(0)
+	entry, _ := json.Marshal(cache.Entry{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Value: map[string]interface{}{"energy": map[string]interface{}{"total": 42}}})
+	err := backend.Set(context.Background(), "device_"+testDeviceId+"_service_"+testServiceId, entry, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conv, err := converter.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	selectionClient := deviceSelection.NewClient(config.DeviceSelectionUrl)
+	verifier := verification.New(config)
+	remoteCache := cache.NewRemoteWithBackend(config, backend, nil, selectionClient)
+	server := httptest.NewServer(Router(config, nil, verifier, remoteCache, conv, selectionClient))
+	t.Cleanup(server.Close)
+	unauthenticatedServer := httptest.NewServer(UnauthenticatedRouter(config, nil, verifier, remoteCache, conv, selectionClient))
+	t.Cleanup(unauthenticatedServer.Close)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user"}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testServer{
+		backend:            backend,
+		cache:              remoteCache,
+		verifier:           verifier,
+		url:                server.URL,
+		unauthenticatedUrl: unauthenticatedServer.URL,
+		token:              "Bearer " + token,
+	}
+}
+
+// request sends an authenticated request and returns the response with the body read
+func (server *testServer) request(t *testing.T, method string, url string, body string) (*http.Response, []byte) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", server.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, respBody
+}
+
+// fakeSelection returns the same selectables for all criteria, or fails with err
+type fakeSelection struct {
+	deviceSelection.Client
+	selectables []dsmodel.Selectable
+	err         error
+}
+
+func (selection fakeSelection) GetSelectables(_ string, _ []models.DeviceGroupFilterCriteria, _ *deviceSelection.GetSelectablesOptions) ([]dsmodel.Selectable, int, error) {
+	if selection.err != nil {
+		return nil, http.StatusBadGateway, selection.err
+	}
+	return selection.selectables, http.StatusOK, nil
+}
+
+// testSecondDeviceId is the second device of the group returned by newGroupCache
+const testSecondDeviceId = "urn:infai:ses:device:d42d8d24-f2a2-4dd7-8ad3-4cabfb6f8062"
+
+// newGroupCache returns a cache with the device group "group" of two devices. Columns with the criteria of the function "function"
+// are expanded to the paths energy.total and energy.today of the service testServiceId of each device, unless selectionErr is set.
+func newGroupCache(t *testing.T, selectionErr error) *cache.RemoteCache {
+	log.InitForTest()
+	ctx := context.Background()
+	backend := cache.NewMemoryBackend(0)
+	deviceGroup, _ := json.Marshal(models.DeviceGroup{Id: "group", DeviceIds: []string{testDeviceId, testSecondDeviceId}})
+	function, _ := json.Marshal(models.Function{Id: "function", ConceptId: "concept"})
+	for key, value := range map[string][]byte{"device_group_group": deviceGroup, "function_function": function} {
+		err := backend.Set(ctx, key, value, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths := map[string][]dsmodel.PathOption{testServiceId: {{Path: "energy.total"}, {Path: "energy.today"}}}
+	return cache.NewRemoteWithBackend(&configuration.ConfigStruct{}, backend, nil, fakeSelection{selectables: []dsmodel.Selectable{
+		{Device: &models.Device{Id: testDeviceId}, ServicePathOptions: paths},
+		{Device: &models.Device{Id: testSecondDeviceId}, ServicePathOptions: paths},
+	}, err: selectionErr})
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := newTestServer(t, &configuration.ConfigStruct{RequestTimeout: "100ms"})
+	start := time.Now()
+	body := `[{"deviceId": "slow", "serviceId": "` + testServiceId + `", "columns": [{"name": "energy.total"}]}]`
+	resp, _ := server.request(t, http.MethodPost, server.url+"/queries/v2", body)
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Error("expected gateway timeout", resp.StatusCode)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("request was not cancelled in time")
+	}
+}
+
+func freePort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
@@ -0,0 +1,110 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/columnar"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+)
+
+const arrowBatchRows = 64 * 1024
+
+// columnType determines the type of a requested column from the field description of its query.
+// If the query is unknown, e.g. for cached values, the type is guessed from the values.
+func columnType(column model.QueriesRequestElementColumn, fields []timescale.Field, fieldIndex int, rows [][]interface{}) columnar.Type {
+	t := columnar.String
+	if fieldIndex < len(fields) {
+		t = columnar.TypeFromDataTypeName(fields[fieldIndex].DataTypeName)
+	} else {
+		for _, row := range rows {
+			if fieldIndex < len(row) {
+				if guessed, ok := columnar.TypeOf(row[fieldIndex]); ok {
+					t = guessed
+					break
+				}
+			}
+		}
+	}
+	if t == columnar.Int64 && column.TargetCharacteristicId != nil {
+		t = columnar.Float64 // conversions might produce fractions
+	}
+	return t
+}
+
+// tableColumns returns the columns of the table format: time followed by all requested columns
+func tableColumns(request []model.QueriesRequestElement, fields [][]timescale.Field, data [][][]interface{}) []columnar.Column {
+	columns := []columnar.Column{{Name: "time", Type: columnar.Timestamp}}
+	for i := range request {
+		var elementFields []timescale.Field
+		if i < len(fields) {
+			elementFields = fields[i]
+		}
+		var rows [][]interface{}
+		if i < len(data) {
+			rows = data[i]
+		}
+		for j, column := range request[i].Columns {
+			columns = append(columns, columnar.Column{Name: column.Name, Type: columnType(column, elementFields, j+1, rows)})
+		}
+	}
+	return columnar.UniqueNames(columns)
+}
+
+// selectionColumns returns the columns of the long format used for device group and location downloads.
+// The type of the value column is the common type of the value fields of all expanded elements, numbers are always written as float.
+func selectionColumns(fields [][]timescale.Field) []columnar.Column {
+	valueTypes := []columnar.Type{}
+	for _, elementFields := range fields {
+		for _, field := range elementFields[min(1, len(elementFields)):] {
+			t := columnar.TypeFromDataTypeName(field.DataTypeName)
+			if t == columnar.Int64 {
+				t = columnar.Float64
+			}
+			valueTypes = append(valueTypes, t)
+		}
+	}
+	return []columnar.Column{
+		{Name: "time", Type: columnar.Timestamp},
+		{Name: "device_id", Type: columnar.String},
+		{Name: "service_id", Type: columnar.String},
+		{Name: "column", Type: columnar.String},
+		{Name: "value", Type: columnar.Common(valueTypes...)},
+	}
+}
+
+func writeArrow(writer http.ResponseWriter, columns []columnar.Column, rows [][]interface{}) error {
+	writer.Header().Set("Content-Type", columnar.ArrowStreamContentType)
+	arrowWriter := columnar.NewArrowWriter(writer, columns)
+	for start := 0; start < len(rows); start += arrowBatchRows {
+		end := min(start+arrowBatchRows, len(rows))
+		err := arrowWriter.WriteRows(rows[start:end])
+		if err != nil {
+			return err
+		}
+	}
+	return arrowWriter.Close()
+}
+
+func stringOrNil(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
@@ -0,0 +1,46 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/columnar"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+)
+
+func TestSelectionColumns(t *testing.T) {
+	field := func(dataTypeName string) timescale.Field {
+		return timescale.Field{DataTypeName: dataTypeName}
+	}
+	for _, c := range []struct {
+		fields   [][]timescale.Field
+		expected columnar.Type
+	}{
+		{fields: [][]timescale.Field{{field("timestamptz"), field("int8")}, {field("timestamptz"), field("float8")}}, expected: columnar.Float64},
+		// the first element must not decide the type alone
+		{fields: [][]timescale.Field{{field("timestamptz"), field("int8")}, {field("timestamptz"), field("text")}}, expected: columnar.String},
+		{fields: [][]timescale.Field{{field("timestamptz"), field("bool")}, {field("timestamptz"), field("bool"), field("bool")}}, expected: columnar.Bool},
+		{fields: nil, expected: columnar.String},
+	} {
+		columns := selectionColumns(c.fields)
+		actual := columns[len(columns)-1]
+		if actual.Name != "value" || actual.Type != c.expected {
+			t.Errorf("expected value column of type %v, got %#v for %#v", c.expected, actual, c.fields)
+		}
+	}
+}
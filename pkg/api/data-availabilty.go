@@ -47,6 +47,8 @@ func init() {
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      429
+// @Failure      503
 // @Failure      500
 // @Router       /data-availability [GET]
 func DataAvailabilityEndpoint(router gin.IRouter, _ configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, _ *cache.RemoteCache, _ *converter.Converter, _ deviceSelection.Client) {
@@ -59,7 +61,7 @@ func DataAvailabilityEndpoint(router gin.IRouter, _ configuration.Config, wrappe
 			c.Error(errors.Join(err, model.ErrBadRequest))
 			return
 		}
-		access, err := verifier.VerifyAccessOnce(model.QueriesRequestElement{
+		access, err := verifier.VerifyAccessOnce(request.Context(), model.QueriesRequestElement{
 			DeviceId: &deviceId,
 		}, getToken(request), userId)
 		if err != nil {
@@ -70,7 +72,7 @@ func DataAvailabilityEndpoint(router gin.IRouter, _ configuration.Config, wrappe
 			c.Error(errors.Join(errors.New("not found"), model.ErrNotFound))
 			return
 		}
-		response, err := wrapper.GetDataAvailability(deviceId)
+		response, err := wrapper.GetDataAvailability(request.Context(), deviceId)
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrInternalServerError))
 			return
@@ -17,28 +17,30 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"io"
-	"math"
 	"net/http"
 	"time"
 
 	"github.com/SENERGY-Platform/converter/lib/converter"
 	deviceSelection "github.com/SENERGY-Platform/device-selection/pkg/client"
 	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/SENERGY-Platform/models/go/models"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/columnar"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
-	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/gin-gonic/gin"
 )
 
+const defaultDownloadChunkRows = 100000
+
 func init() {
 	endpoints = append(endpoints, PrepareDownloadEndpoints)
 	unauthenticatedEndpoints = append(unauthenticatedEndpoints, DownloadEndpoints)
@@ -46,17 +48,20 @@ func init() {
 
 // Query godoc
 // @Summary      download
-// @Description  download CSV
+// @Description  download CSV or Parquet file
 // @Accept       json
 // @Produce      plain
 // @Security Bearer
-// @Param        query query string true "JSON encoded QueriesRequestElement"
+// @Param        query query string true "JSON encoded QueriesRequestElement. Device group and location requests are written in long format with the columns time, device_id, service_id, column and value"
 // @Param        time_format query string false "Textual representation of the date 'Mon Jan 2 15:04:05 -0700 MST 2006'. Example: 2006-01-02T15:04:05.000Z07:00 would format timestamps as rfc3339 with ms precision. Find details here: https://golang.org/pkg/time/#Time.Format"
-// @Success      200 {file}  CSV file
+// @Param        format query string false "file format, csv (default) or parquet. Parquet files use the column types of the database and ignore time_format"
+// @Success      200 {file}  CSV or Parquet file
 // @Failure      400
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      429
+// @Failure      503
 // @Failure      500
 // @Router       /download [GET]
 func GetDownload() {} // for doc generation
@@ -67,18 +72,21 @@ func GetDownload() {} // for doc generation
 // @Accept       json
 // @Produce      plain
 // @Security Bearer
-// @Param        query query string true "JSON encoded QueriesRequestElement"
+// @Param        query query string true "JSON encoded QueriesRequestElement. Device group and location requests are written in long format with the columns time, device_id, service_id, column and value"
 // @Param        time_format query string false "Textual representation of the date 'Mon Jan 2 15:04:05 -0700 MST 2006'. Example: 2006-01-02T15:04:05.000Z07:00 would format timestamps as rfc3339 with ms precision. Find details here: https://golang.org/pkg/time/#Time.Format"
+// @Param        format query string false "file format, csv (default) or parquet. Parquet files use the column types of the database and ignore time_format"
 // @Success      200 {string} Secret
 // @Failure      400
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      429
+// @Failure      503
 // @Failure      500
 // @Router       /prepare-download [GET]
 func GetPrepareDownload() {} // for doc generation
 
-func PrepareDownloadEndpoints(router gin.IRouter, config configuration.Config, _ *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, _ *converter.Converter, _ deviceSelection.Client) {
+func PrepareDownloadEndpoints(router gin.IRouter, config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, converter *converter.Converter, _ deviceSelection.Client) {
 	router.GET("/download", func(c *gin.Context) {
 		writer := c.Writer
 		request := c.Request
@@ -86,7 +94,7 @@ func PrepareDownloadEndpoints(router gin.IRouter, config configuration.Config, _
 		if !ok {
 			return
 		}
-		handleCSVDownload(c, prepared.QueriesRequestElement, prepared.TimeFormat, prepared.Token, writer, config)
+		handleDownload(c, prepared, writer, config, wrapper, remoteCache, converter, apiWriteTimeout)
 	})
 
 	router.GET("/prepare-download", func(c *gin.Context) {
@@ -97,7 +105,7 @@ func PrepareDownloadEndpoints(router gin.IRouter, config configuration.Config, _
 			return
 		}
 
-		secret, err := remoteCache.StoreSecretQuery(prepared)
+		secret, err := remoteCache.StoreSecretQuery(request.Context(), prepared)
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrInternalServerError))
 			return
@@ -113,10 +121,10 @@ func PrepareDownloadEndpoints(router gin.IRouter, config configuration.Config, _
 
 // Query godoc
 // @Summary      download
-// @Description  downloads CSV file with previously prepared secret
+// @Description  downloads CSV or Parquet file with previously prepared secret
 // @Produce      plain
 // @Param        secret path string true "secret"
-// @Success      200 {file} CSV file
+// @Success      200 {file} CSV or Parquet file
 // @Failure      400
 // @Failure      401
 // @Failure      403
@@ -126,9 +134,9 @@ func PrepareDownloadEndpoints(router gin.IRouter, config configuration.Config, _
 func DownloadEndpoints(router gin.IRouter, config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, converter *converter.Converter, _ deviceSelection.Client) {
 	router.GET("/download/:secret", func(c *gin.Context) {
 		writer := c.Writer
-		prepared, err := remoteCache.GetSecretQuery(c.Param("secret"))
+		prepared, err := remoteCache.GetSecretQuery(c.Request.Context(), c.Param("secret"))
 		if err != nil {
-			if err == memcache.ErrCacheMiss {
+			if errors.Is(err, cache.ErrNotFound) {
 				c.Error(errors.Join(errors.New("not found"), model.ErrNotFound))
 			} else {
 				c.Error(errors.Join(err, model.ErrInternalServerError))
@@ -136,19 +144,36 @@ func DownloadEndpoints(router gin.IRouter, config configuration.Config, wrapper
 			return
 		}
 
-		handleCSVDownload(c, prepared.QueriesRequestElement, prepared.TimeFormat, prepared.Token, writer, config)
+		handleDownload(c, prepared, writer, config, wrapper, remoteCache, converter, unauthenticatedApiWriteTimeout)
 	})
 }
 
+// parseDownloadQuery parses the query parameter of download requests. Queries are accepted if they are valid,
+// even if only a part of them could be decoded.
+func parseDownloadQuery(query string) (element model.QueriesRequestElement, err error) {
+	_ = json.Unmarshal([]byte(query), &element)
+	if !element.Valid() {
+		return element, errors.Join(errors.New("invalid query"), model.ErrBadRequest)
+	}
+	return element, nil
+}
+
 func prepareQueriesRequestElement(c *gin.Context, request *http.Request, verifier *verification.Verifier) (elem model.PreparedQueriesRequestElement, ok bool) {
 	elem = model.PreparedQueriesRequestElement{}
 	query := request.URL.Query().Get("query")
 	timeFormat := request.URL.Query().Get("time_format")
+	format := model.Format(request.URL.Query().Get("format"))
+	if format == "" {
+		format = model.Csv
+	}
+	if format != model.Csv && format != model.Parquet {
+		c.Error(errors.Join(errors.New("invalid format"), model.ErrBadRequest))
+		return elem, false
+	}
 
-	var requestElement model.QueriesRequestElement
-	err := json.Unmarshal([]byte(query), &requestElement)
-	if !requestElement.Valid() {
-		c.Error(errors.Join(errors.New("invalid query"), model.ErrBadRequest))
+	requestElement, err := parseDownloadQuery(query)
+	if err != nil {
+		c.Error(err)
 		return elem, false
 	}
 	zero := 0
@@ -182,6 +207,12 @@ func prepareQueriesRequestElement(c *gin.Context, request *http.Request, verifie
 			end := time.Now().Add(d).Format(time.RFC3339)
 			requestElement.Time.End = &end
 		}
+		requestElement.Time.Last = nil
+		requestElement.Time.Ahead = nil
+	}
+	if requestElement.Time.Start == nil || requestElement.Time.End == nil {
+		c.Error(errors.Join(errors.New("need time range"), model.ErrBadRequest))
+		return elem, false
 	}
 
 	userId, err := getUserId(request)
@@ -189,7 +220,7 @@ func prepareQueriesRequestElement(c *gin.Context, request *http.Request, verifie
 		c.Error(errors.Join(err, model.ErrBadRequest))
 		return elem, false
 	}
-	ok, _, err = verifier.VerifyAccess([]model.QueriesRequestElement{requestElement}, getToken(request), userId)
+	ok, ownerUserIds, err := verifier.VerifyAccess(request.Context(), []model.QueriesRequestElement{requestElement}, getToken(request), userId)
 	if err != nil {
 		c.Error(errors.Join(err, model.ErrInternalServerError))
 		return elem, false
@@ -203,90 +234,349 @@ func prepareQueriesRequestElement(c *gin.Context, request *http.Request, verifie
 		QueriesRequestElement: requestElement,
 		Token:                 getToken(request),
 		TimeFormat:            timeFormat,
+		UserId:                userId,
+		OwnerUserId:           ownerUserIds[0],
+		Format:                format,
 	}, true
 }
 
-func handleCSVDownload(c *gin.Context, requestElement model.QueriesRequestElement, timeFormat string, token string, writer http.ResponseWriter, config configuration.Config) {
-	flusher, ok := writer.(http.Flusher)
-	if !ok {
-		log.Logger.Error("not a flusher")
-		c.Error(errors.Join(errors.New("not a flusher"), model.ErrInternalServerError))
-		return
-	}
-	writer.Header().Set("Content-Type", "application/csv")
-	writer.Header().Set("Content-Disposition", "attachment; filename=\"download.csv\"")
-	csvWriter := csv.NewWriter(writer)
-	headers := []string{"time"}
-	for j := range requestElement.Columns {
-		headers = append(headers, requestElement.Columns[j].Name)
-	}
-	err := csvWriter.Write(headers)
+func handleDownload(c *gin.Context, prepared model.PreparedQueriesRequestElement, writer http.ResponseWriter, config configuration.Config,
+	wrapper *timescale.Wrapper, remoteCache *cache.RemoteCache, conv *converter.Converter, writeTimeout time.Duration) {
+
+	flush := func() error {
+		return flushStream(writer, writeTimeout)
+	}
+	var sink downloadSink
+	var csvWriter *csv.Writer
+	if prepared.Format == model.Parquet {
+		writer.Header().Set("Content-Type", columnar.ParquetContentType)
+		writer.Header().Set("Content-Disposition", "attachment; filename=\"download.parquet\"")
+		prepared.TimeFormat = "" // parquet has native timestamps
+		columns := func(fields []timescale.Field, rows [][]interface{}) []columnar.Column {
+			return tableColumns([]model.QueriesRequestElement{prepared.QueriesRequestElement}, [][]timescale.Field{fields}, [][][]interface{}{rows})
+		}
+		sink = &parquetDownloadSink{writer: writer, columns: columns, flush: flush}
+	} else {
+		writer.Header().Set("Content-Type", "application/csv")
+		writer.Header().Set("Content-Disposition", "attachment; filename=\"download.csv\"")
+		csvWriter = csv.NewWriter(writer)
+		headers := []string{"time"}
+		if isSelection(prepared.QueriesRequestElement) {
+			headers = append(headers, "device_id", "service_id", "column", "value")
+		} else {
+			for j := range prepared.Columns {
+				headers = append(headers, prepared.Columns[j].Name)
+			}
+		}
+		err := csvWriter.Write(headers)
+		if err != nil {
+			c.Error(errors.Join(err, model.ErrInternalServerError))
+			return
+		}
+		csvWriter.Flush()
+		sink = &csvDownloadSink{csvWriter: csvWriter, flush: flush}
+	}
+	err := flush() // no http.Error from now on! Use panic(http.ErrAbortHandler)
 	if err != nil {
-		c.Error(errors.Join(err, model.ErrInternalServerError))
-		return
+		log.Logger.Error("failed to flush download header", attributes.ErrorKey, err)
+		panic(http.ErrAbortHandler)
 	}
-	flusher.Flush() // no http.Error from now on! Use panic(http.ErrAbortHandler)
 
-	endTime, err := time.Parse(time.RFC3339, *requestElement.Time.End)
+	beforeDownload := time.Now()
+	err = writeDownload(c.Request.Context(), prepared, sink, config, wrapper, remoteCache, conv)
+	if err == nil {
+		err = sink.Close()
+	}
 	if err != nil {
-		log.Logger.Error("failed to parse end time", attributes.ErrorKey, err)
+		log.Logger.Error("failed to write download", attributes.ErrorKey, err)
+		if csvWriter != nil {
+			// mark the file as incomplete before the connection is aborted, the user might only get to see the file
+			_ = csvWriter.Write([]string{"ERROR: download incomplete", err.Error()})
+			csvWriter.Flush()
+			_ = flush()
+		} // parquet files without footer are invalid anyway
 		panic(http.ErrAbortHandler)
 	}
-	initialEndValue := endTime.Unix()
-	startTime, err := time.Parse(time.RFC3339, *requestElement.Time.Start)
+	if config.Debug {
+		log.Logger.Debug("Download took " + time.Since(beforeDownload).String())
+	}
+}
+
+// downloadSink receives the rows of a download
+type downloadSink interface {
+	Write(row []interface{}) error
+	// EndChunk is called after the rows of each chunk with the field descriptions of the chunk's query
+	EndChunk(fields []timescale.Field) error
+	Close() error
+}
+
+type csvDownloadSink struct {
+	csvWriter *csv.Writer
+	flush     func() error
+}
+
+func (sink *csvDownloadSink) Write(row []interface{}) error {
+	record, err := csvRecord(row)
 	if err != nil {
-		log.Logger.Error("failed to parse start time", attributes.ErrorKey, err)
-		panic(http.ErrAbortHandler)
+		return err
 	}
+	return sink.csvWriter.Write(record)
+}
+
+func (sink *csvDownloadSink) EndChunk(_ []timescale.Field) error {
+	sink.csvWriter.Flush()
+	if sink.csvWriter.Error() != nil {
+		return sink.csvWriter.Error()
+	}
+	return sink.flush()
+}
+
+func (sink *csvDownloadSink) Close() error {
+	return nil
+}
+
+// parquetDownloadSink writes each chunk as row group. The column types are taken from the fields of the first chunk,
+// device group and location downloads set them up front with setColumns.
+type parquetDownloadSink struct {
+	writer        io.Writer
+	columns       func(fields []timescale.Field, rows [][]interface{}) []columnar.Column
+	flush         func() error
+	parquetWriter *columnar.ParquetWriter
+	rows          [][]interface{}
+}
 
-	startValue := startTime.Unix()
-	var chunkSize int64 = 30 * 60 * 60 * 24
-	endValue := int64(math.Min(float64(startValue+chunkSize), float64(initialEndValue)))
+func (sink *parquetDownloadSink) Write(row []interface{}) error {
+	sink.rows = append(sink.rows, row)
+	return nil
+}
 
-	for startValue < initialEndValue { // loop over time chunks
-		start := time.Unix(startValue, 0).Format(time.RFC3339)
-		requestElement.Time.Start = &start
-		end := time.Unix(endValue, 0).Format(time.RFC3339)
-		requestElement.Time.End = &end
-		// post /queries
-		b, err := json.Marshal([]model.QueriesRequestElement{requestElement})
+// setColumns fixes the columns independent of the fields of the first chunk
+func (sink *parquetDownloadSink) setColumns(columns []columnar.Column) {
+	sink.columns = func(_ []timescale.Field, _ [][]interface{}) []columnar.Column {
+		return columns
+	}
+}
+
+func (sink *parquetDownloadSink) EndChunk(fields []timescale.Field) (err error) {
+	if sink.parquetWriter == nil {
+		sink.parquetWriter, err = columnar.NewParquetWriter(sink.writer, sink.columns(fields, sink.rows))
 		if err != nil {
-			log.Logger.Error("failed to marshal query request", attributes.ErrorKey, err)
-			panic(http.ErrAbortHandler)
+			return err
 		}
-		req, err := http.NewRequest(http.MethodPost, "http://localhost:"+config.ApiPort+"/queries?format=table&order_column_index=0&order_direction=asc&time_format="+timeFormat, bytes.NewBuffer(b))
+	}
+	err = sink.parquetWriter.WriteRowGroup(sink.rows)
+	if err != nil {
+		return err
+	}
+	sink.rows = nil
+	return sink.flush()
+}
+
+func (sink *parquetDownloadSink) Close() error {
+	if sink.parquetWriter == nil {
+		err := sink.EndChunk(nil)
 		if err != nil {
-			log.Logger.Error("failed to create query request", attributes.ErrorKey, err)
-			panic(http.ErrAbortHandler)
+			return err
 		}
-		req.Header.Set("Authorization", token)
-		resp, err := http.DefaultClient.Do(req)
+	}
+	err := sink.parquetWriter.Close()
+	if err != nil {
+		return err
+	}
+	return sink.flush()
+}
+
+// writeDownload queries the database directly and passes all rows to sink.
+// Device group and location requests are expanded like in QueriesV2Endpoint. Each value of the expanded devices
+// and services is passed on as row of the long format: time, device_id, service_id, column and value.
+func writeDownload(ctx context.Context, prepared model.PreparedQueriesRequestElement, sink downloadSink, config configuration.Config,
+	wrapper *timescale.Wrapper, remoteCache *cache.RemoteCache, conv *converter.Converter) error {
+
+	if !isSelection(prepared.QueriesRequestElement) {
+		return writeDownloadElement(ctx, prepared, prepared.QueriesRequestElement, sink.Write, sink.EndChunk, config, wrapper, remoteCache, conv)
+	}
+	elements, _, err := expandSelection(ctx, remoteCache, prepared.UserId, prepared.Token, prepared.QueriesRequestElement)
+	if err != nil {
+		return err
+	}
+	if parquetSink, ok := sink.(*parquetDownloadSink); ok {
+		// all elements share the value column, its type has to fit every element and not just the first chunk
+		fields, err := selectionFields(ctx, prepared, elements, wrapper)
+		if err != nil {
+			return err
+		}
+		parquetSink.setColumns(selectionColumns(fields))
+	}
+	for _, element := range elements {
+		write := func(row []interface{}) error {
+			for j := 1; j < len(row) && j <= len(element.Columns); j++ {
+				if row[j] == nil {
+					continue
+				}
+				err := sink.Write([]interface{}{row[0], *element.DeviceId, *element.ServiceId, element.Columns[j-1].Name, row[j]})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		err = writeDownloadElement(ctx, prepared, element, write, sink.EndChunk, config, wrapper, remoteCache, conv)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectionFields returns the field descriptions of the queries of all elements without reading any rows
+func selectionFields(ctx context.Context, prepared model.PreparedQueriesRequestElement, elements []model.QueriesRequestElement,
+	wrapper *timescale.Wrapper) (fields [][]timescale.Field, err error) {
+
+	zero := 0
+	probes := make([]model.QueriesRequestElement, len(elements))
+	ownerUserIds := make([]string, len(elements))
+	for i, element := range elements {
+		element.Limit = &zero
+		probes[i] = element
+		ownerUserIds[i] = prepared.OwnerUserId
+	}
+	queries, err := wrapper.GenerateQueries(ctx, probes, prepared.UserId, ownerUserIds, "", []models.Device{})
+	if err != nil {
+		return nil, err
+	}
+	_, fields, err = wrapper.ExecuteQueriesWithFields(ctx, queries)
+	return fields, err
+}
+
+// writeDownloadElement passes the rows of a single device, service or export to write.
+// Raw values are paged by time in chunks of config.DownloadChunkRows rows. Each chunk is passed on after its query is done,
+// so slow clients don't hold database connections.
+// Aggregated values are passed on in one chunk, their number of rows is limited by the number of time buckets.
+func writeDownloadElement(ctx context.Context, prepared model.PreparedQueriesRequestElement, requestElement model.QueriesRequestElement,
+	write func(row []interface{}) error, endChunk func(fields []timescale.Field) error, config configuration.Config,
+	wrapper *timescale.Wrapper, remoteCache *cache.RemoteCache, conv *converter.Converter) error {
+
+	ownerUserIds := []string{prepared.OwnerUserId}
+	if requestElement.GroupTime != nil {
+		elements := []model.QueriesRequestElement{requestElement}
+		queries, err := wrapper.GenerateQueries(ctx, elements, prepared.UserId, ownerUserIds, "", []models.Device{})
+		if err != nil {
+			return err
+		}
+		data, fields, err := wrapper.ExecuteQueriesWithFields(ctx, queries)
 		if err != nil {
-			log.Logger.Error("failed to execute query request", attributes.ErrorKey, err)
-			panic(http.ErrAbortHandler)
+			return err
 		}
-		if resp.StatusCode != 200 {
-			reason, err := io.ReadAll(resp.Body)
+		formatted, err := formatResponse(ctx, remoteCache, model.Table, elements, data, 0, model.Asc, prepared.TimeFormat, conv)
+		if err != nil {
+			return err
+		}
+		for _, row := range formatted.([][]interface{}) {
+			err = write(row)
 			if err != nil {
-				log.Logger.Error("failed to read query error response", attributes.ErrorKey, err)
-				panic(http.ErrAbortHandler)
+				return err
 			}
-			log.Logger.Error("query request returned non-200 status", attributes.ErrorKey, string(reason))
-			panic(http.ErrAbortHandler)
 		}
-		var respData [][]interface{}
-		err = json.NewDecoder(resp.Body).Decode(&respData)
+		return endChunk(fields[0])
+	}
+
+	sourceCharacteristicIds, extensions, err := prepareConversions(ctx, remoteCache, []model.QueriesRequestElement{requestElement})
+	if err != nil {
+		return err
+	}
+	chunkRows := int(config.DownloadChunkRows)
+	if chunkRows <= 0 {
+		chunkRows = defaultDownloadChunkRows
+	}
+	query := func(start string, limit int, handle func(row []interface{}) error) error {
+		chunk := requestElement
+		chunkTime := requestElement.Time.Copy()
+		chunkTime.Start = &start
+		chunk.Time = &chunkTime
+		chunk.Limit = &limit
+		chunk.StableOrder = true // rows with the same time have to be read in the same order by every chunk
+		queries, err := wrapper.GenerateQueries(ctx, []model.QueriesRequestElement{chunk}, prepared.UserId, ownerUserIds, "", []models.Device{})
 		if err != nil {
-			log.Logger.Error("failed to decode query response", attributes.ErrorKey, err)
-			panic(http.ErrAbortHandler)
+			return err
 		}
-		err = writeCsv(respData, csvWriter)
+		var rows [][]interface{}
+		fields, err := wrapper.StreamQuery(ctx, queries[0].Sql, queries[0].Args, func(row []interface{}) error {
+			rows = append(rows, row)
+			return nil
+		})
 		if err != nil {
-			log.Logger.Error("failed to write csv", attributes.ErrorKey, err)
-			panic(http.ErrAbortHandler)
+			return err
 		}
+		for _, row := range rows {
+			err = handle(row)
+			if err != nil {
+				return err
+			}
+		}
+		return endChunk(fields)
+	}
+	return pageByTime(*requestElement.Time.Start, chunkRows, requestElement.Limit, query, func(row []interface{}) error {
+		keep, err := formatRow(requestElement, row, nil, conv, sourceCharacteristicIds[0], extensions[0], prepared.TimeFormat)
+		if err != nil || !keep {
+			return err
+		}
+		return write(row)
+	})
+}
+
+// pageByTime reads rows ordered by time in chunks of chunkRows rows until limit rows are read, all rows if limit is nil.
+// query has to return up to limit rows with a time after start. Rows can share a time, so each chunk after the first starts
+// a microsecond, the resolution of the database, before the last row and skips the rows at that time which were already read.
+// Rows with the same time have to be ordered by their values, otherwise each chunk could skip different rows.
+// Rows with equal values are interchangeable, so skipping them by number is enough.
+func pageByTime(start string, chunkRows int, limit *int, query func(start string, limit int, handle func(row []interface{}) error) error,
+	handle func(row []interface{}) error) error {
 
-		startValue = endValue
-		endValue = int64(math.Min(float64(endValue+chunkSize), float64(initialEndValue)))
+	remaining := -1 // unlimited
+	if limit != nil {
+		remaining = *limit
+	}
+	var last time.Time
+	atLast := 0 // rows read with the time last
+	for remaining != 0 {
+		chunkLimit := chunkRows
+		if remaining > 0 && remaining < chunkLimit {
+			chunkLimit = remaining
+		}
+		skip := atLast
+		rows := 0
+		err := query(start, chunkLimit+skip, func(row []interface{}) error {
+			ts, ok := row[0].(time.Time)
+			if skip > 0 && ok && ts.Equal(last) {
+				skip--
+				return nil
+			}
+			skip = 0
+			rows++
+			if ok {
+				if ts.Equal(last) {
+					atLast++
+				} else {
+					last = ts
+					atLast = 1
+				}
+			}
+			return handle(row)
+		})
+		if err != nil {
+			return err
+		}
+		if rows < chunkLimit {
+			return nil
+		}
+		if remaining > 0 {
+			remaining -= rows
+		}
+		start = last.Add(-time.Microsecond).Format(time.RFC3339Nano)
 	}
+	return nil
+}
+
+func isSelection(element model.QueriesRequestElement) bool {
+	return element.DeviceGroupId != nil || element.LocationId != nil
 }
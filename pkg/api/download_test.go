@@ -0,0 +1,155 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+)
+
+func TestPageByTime(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// rows 2-5 share a time at the boundary of the first and second chunk, rows 6-9 fill a whole chunk
+	times := []time.Time{base, base.Add(time.Second), base.Add(2 * time.Second), base.Add(2 * time.Second), base.Add(2 * time.Second), base.Add(2 * time.Second),
+		base.Add(3 * time.Second), base.Add(3 * time.Second), base.Add(3 * time.Second), base.Add(3 * time.Second), base.Add(4 * time.Second)}
+	table := [][]interface{}{}
+	for i, ts := range times {
+		table = append(table, []interface{}{ts, i})
+	}
+	// query behaves like the database: time after start, ordered by time, limited
+	query := func(start string, limit int, handle func(row []interface{}) error) error {
+		startTime, err := time.Parse(time.RFC3339Nano, start)
+		if err != nil {
+			return err
+		}
+		for _, row := range table {
+			if limit == 0 {
+				break
+			}
+			if row[0].(time.Time).After(startTime) {
+				limit--
+				err = handle(append([]interface{}{}, row...))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	start := base.Add(-time.Second).Format(time.RFC3339)
+	for _, limit := range []*int{nil, func() *int { i := 7; return &i }()} {
+		actual := []interface{}{}
+		err := pageByTime(start, 4, limit, query, func(row []interface{}) error {
+			actual = append(actual, row[1])
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []interface{}{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+		if limit != nil {
+			expected = expected[:*limit]
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Error("unexpected rows", limit, actual)
+		}
+	}
+}
+
+func TestPageByTimeSameTime(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// several rows share a time, some of them also share their value
+	table := [][]interface{}{{base, 9}, {base.Add(time.Second), 5}, {base.Add(time.Second), 3}, {base.Add(time.Second), 3},
+		{base.Add(time.Second), 1}, {base.Add(time.Second), 4}, {base.Add(time.Second), 2}, {base.Add(2 * time.Second), 0}}
+	// query behaves like the database with a stable order: rows with the same time come in any order, unless sorted by their values
+	query := func(start string, limit int, handle func(row []interface{}) error) error {
+		startTime, err := time.Parse(time.RFC3339Nano, start)
+		if err != nil {
+			return err
+		}
+		rows := [][]interface{}{}
+		for _, row := range table {
+			if row[0].(time.Time).After(startTime) {
+				rows = append(rows, append([]interface{}{}, row...))
+			}
+		}
+		rand.Shuffle(len(rows), func(i, j int) { rows[i], rows[j] = rows[j], rows[i] })
+		sort.SliceStable(rows, func(i, j int) bool {
+			if !rows[i][0].(time.Time).Equal(rows[j][0].(time.Time)) {
+				return rows[i][0].(time.Time).Before(rows[j][0].(time.Time))
+			}
+			return rows[i][1].(int) < rows[j][1].(int)
+		})
+		for i := 0; i < limit && i < len(rows); i++ {
+			err = handle(rows[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := base.Add(-time.Second).Format(time.RFC3339)
+	for chunkRows := 1; chunkRows <= len(table); chunkRows++ {
+		actual := []interface{}{}
+		err := pageByTime(start, chunkRows, nil, query, func(row []interface{}) error {
+			actual = append(actual, row[1])
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual, []interface{}{9, 1, 2, 3, 3, 4, 5, 0}) {
+			t.Error("unexpected rows", chunkRows, actual)
+		}
+	}
+}
+
+func TestWriteDownloadSelection(t *testing.T) {
+	remoteCache := newGroupCache(t, nil)
+	config := &configuration.ConfigStruct{DownloadChunkRows: 10}
+	groupId := "group"
+	start, end := "2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z"
+	prepared := model.PreparedQueriesRequestElement{
+		QueriesRequestElement: model.QueriesRequestElement{
+			DeviceGroupId: &groupId,
+			Time:          &model.QueriesRequestElementTime{Start: &start, End: &end},
+			Columns: []model.QueriesRequestElementColumn{{
+				Criteria: models.DeviceGroupFilterCriteria{FunctionId: "function", AspectId: "aspect"},
+			}},
+		},
+		UserId:      "user",
+		OwnerUserId: "user",
+		Format:      model.Parquet,
+	}
+	sink := &parquetDownloadSink{writer: &bytes.Buffer{}, flush: func() error { return nil }}
+	// the group expands to two elements, reading their column types has to reach the database
+	err := writeDownload(context.Background(), prepared, sink, config, timescale.NewWrapperWithoutDatabase(config), remoteCache, nil)
+	if !errors.Is(err, timescale.ErrNotConnected) {
+		t.Error("expected the column types to be read from the database", err)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/converter/lib/converter"
+	deviceSelection "github.com/SENERGY-Platform/device-selection/pkg/client"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout limits the time each dependency may take to answer a readiness check
+const healthCheckTimeout = 5 * time.Second
+
+func init() {
+	unauthenticatedEndpoints = append(unauthenticatedEndpoints, HealthEndpoints)
+}
+
+// Query godoc
+// @Summary      liveness
+// @Description  answers as long as the service is running
+// @Produce      json
+// @Success      200 {object} model.HealthResponse
+// @Router       /health/live [GET]
+func GetHealthLive() {} // for doc generation
+
+// Query godoc
+// @Summary      readiness
+// @Description  checks the database and cache backend, and the permissions and device-repository services if health_check_upstreams is set
+// @Produce      json
+// @Success      200 {object} model.HealthResponse
+// @Failure      503 {object} model.HealthResponse
+// @Router       /health/ready [GET]
+func HealthEndpoints(router gin.IRouter, config configuration.Config, wrapper *timescale.Wrapper, _ *verification.Verifier, remoteCache *cache.RemoteCache, _ *converter.Converter, _ deviceSelection.Client) {
+	router.GET("/health/live", func(c *gin.Context) {
+		c.JSON(http.StatusOK, model.HealthResponse{Status: model.HealthOk})
+	})
+
+	router.GET("/health/ready", func(c *gin.Context) {
+		if shuttingDown.Load() {
+			c.JSON(http.StatusServiceUnavailable, model.HealthResponse{Status: model.HealthError, Checks: map[string]model.HealthCheck{
+				"shutdown": {Status: model.HealthError, Error: "shutting down"},
+			}})
+			return
+		}
+		checks := map[string]func(ctx context.Context) error{
+			"database": wrapper.Ping,
+			"cache":    remoteCache.Ping,
+		}
+		if config.HealthCheckUpstreams {
+			checks["permissions"] = upstreamCheck(config.PermissionsUrl)
+			checks["device-repository"] = upstreamCheck(config.DeviceRepoUrl)
+		}
+		response := runHealthChecks(c.Request.Context(), checks)
+		code := http.StatusOK
+		if response.Status != model.HealthOk {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, response)
+	})
+}
+
+// runHealthChecks runs all checks in parallel. The response is ok if all checks are ok.
+func runHealthChecks(ctx context.Context, checks map[string]func(ctx context.Context) error) model.HealthResponse {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	response := model.HealthResponse{Status: model.HealthOk, Checks: map[string]model.HealthCheck{}}
+	mux := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := model.HealthCheck{Status: model.HealthOk}
+			err := check(ctx)
+			if err != nil {
+				result = model.HealthCheck{Status: model.HealthError, Error: err.Error()}
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			response.Checks[name] = result
+			if err != nil {
+				response.Status = model.HealthError
+			}
+		}()
+	}
+	wg.Wait()
+	return response
+}
+
+// upstreamCheck checks that the service at url answers without a server error. Any other response,
+// e.g. 404 for the root path, shows that the service is reachable.
+func upstreamCheck(url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+		}
+		return nil
+	}
+}
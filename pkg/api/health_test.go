@@ -0,0 +1,47 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+)
+
+func TestHealth(t *testing.T) {
+	server := newTestServer(t, &configuration.ConfigStruct{})
+	resp, _ := server.request(t, http.MethodGet, server.unauthenticatedUrl+"/health/live", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Error("unexpected liveness status", resp.StatusCode)
+	}
+	resp, body := server.request(t, http.MethodGet, server.unauthenticatedUrl+"/health/ready", "")
+	var health model.HealthResponse
+	err := json.Unmarshal(body, &health)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the test runs without database
+	if resp.StatusCode != http.StatusServiceUnavailable || health.Status != model.HealthError {
+		t.Error("expected not ready", resp.StatusCode, health)
+	}
+	if health.Checks["database"].Status != model.HealthError || health.Checks["cache"].Status != model.HealthOk {
+		t.Error("unexpected checks", health.Checks)
+	}
+}
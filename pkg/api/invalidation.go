@@ -0,0 +1,85 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/SENERGY-Platform/converter/lib/converter"
+	deviceSelection "github.com/SENERGY-Platform/device-selection/pkg/client"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/invalidation"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
+	"github.com/gin-gonic/gin"
+)
+
+const cacheInvalidationSecretHeader = "X-Cache-Invalidation-Secret"
+
+func init() {
+	unauthenticatedEndpoints = append(unauthenticatedEndpoints, CacheInvalidationEndpoint)
+}
+
+// Query godoc
+// @Summary      invalidate cache
+// @Description  Webhook for change notifications, evicts cached entities and permissions. Only available if a cache invalidation secret is configured, which has to be sent in the X-Cache-Invalidation-Secret header.
+// @Description  Kinds are device, device_group, location, service, function, concept, device_type and export.
+// @Description  Single instance only: entries are evicted from memcached or Redis for all instances, but the in-process caches (L1, permissions, memory backend) are only cleared on the instance receiving the request. With multiple instances, publish the changes to the cache invalidation topics instead.
+// @Accept       json
+// @Param        X-Cache-Invalidation-Secret header string true "configured secret"
+// @Param        payload body []invalidation.Event true "changed entities"
+// @Success      204
+// @Failure      400
+// @Failure      401
+// @Failure      500
+// @Router       /cache/invalidate [POST]
+func CacheInvalidationEndpoint(router gin.IRouter, config configuration.Config, _ *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, _ *converter.Converter, _ deviceSelection.Client) {
+	if config.CacheInvalidationSecret == "" {
+		return
+	}
+	invalidator := invalidation.New(remoteCache, verifier)
+	router.POST("/cache/invalidate", func(c *gin.Context) {
+		request := c.Request
+		if subtle.ConstantTimeCompare([]byte(request.Header.Get(cacheInvalidationSecretHeader)), []byte(config.CacheInvalidationSecret)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		events := []invalidation.Event{}
+		err := json.NewDecoder(request.Body).Decode(&events)
+		if err != nil {
+			c.Error(errors.Join(err, model.ErrBadRequest))
+			return
+		}
+		for _, event := range events {
+			if !event.Valid() {
+				c.Error(errors.Join(errors.New("Invalid request body"), model.ErrBadRequest))
+				return
+			}
+		}
+		err = invalidator.Invalidate(request.Context(), invalidation.SourceWebhook, events...)
+		if err != nil {
+			c.Error(errors.Join(err, model.ErrInternalServerError))
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,69 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+)
+
+func TestCacheInvalidationEndpoint(t *testing.T) {
+	server := newTestServer(t, &configuration.ConfigStruct{CacheInvalidationSecret: "secret"})
+	ctx := context.Background()
+	err := server.backend.Set(ctx, "service_invalidated", []byte("{}"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Post(server.unauthenticatedUrl+"/cache/invalidate", "application/json", strings.NewReader(`[{"kind": "service", "id": "invalidated"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Error("expected unauthorized without secret", resp.StatusCode)
+	}
+	for _, tc := range []struct {
+		body     string
+		expected int
+	}{
+		{body: `[{"kind": "service", "id": "invalidated"}]`, expected: http.StatusNoContent},
+		{body: `[{"kind": "unknown", "id": "invalidated"}]`, expected: http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest(http.MethodPost, server.unauthenticatedUrl+"/cache/invalidate", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(cacheInvalidationSecretHeader, "secret")
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.expected {
+			t.Error("unexpected status", tc.body, resp.StatusCode)
+		}
+	}
+	_, err = server.backend.Get(ctx, "service_invalidated")
+	if err == nil {
+		t.Error("service still cached")
+	}
+}
@@ -17,6 +17,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -60,6 +61,8 @@ type queriesRequestElementColumn struct {
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      429
+// @Failure      503
 // @Failure      500
 // @Router       /last-values [POST]
 func LastValuesEndpoint(router gin.IRouter, config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, converter *converter.Converter, _ deviceSelection.Client) {
@@ -83,6 +86,7 @@ func LastValuesEndpoint(router gin.IRouter, config configuration.Config, wrapper
 
 func lastValueHandler(config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, converter *converter.Converter) func(request *http.Request) ([]model.LastValuesResponseElement, int, error) {
 	return func(request *http.Request) ([]model.LastValuesResponseElement, int, error) {
+		ctx := request.Context()
 		start := time.Now()
 
 		var requestElements []model.LastValuesRequestElement
@@ -152,7 +156,7 @@ func lastValueHandler(config configuration.Config, wrapper *timescale.Wrapper, v
 		if err != nil {
 			return nil, http.StatusBadRequest, err
 		}
-		ok, ownerUserIds, err := verifier.VerifyAccess(fullRequestElements, getToken(request), userId)
+		ok, ownerUserIds, err := verifier.VerifyAccess(ctx, fullRequestElements, getToken(request), userId)
 		if err != nil {
 			return nil, http.StatusInternalServerError, err
 		}
@@ -165,6 +169,7 @@ func lastValueHandler(config configuration.Config, wrapper *timescale.Wrapper, v
 
 		dbRequestElements := []model.QueriesRequestElement{}
 		dbRequestIndices := []int{}
+		dbOwnerUserIds := []string{}
 
 		beforeCache := time.Now()
 		raw := make([][][]interface{}, len(fullRequestElements))
@@ -175,17 +180,22 @@ func lastValueHandler(config configuration.Config, wrapper *timescale.Wrapper, v
 		for i := range fullRequestElements {
 			i := i
 			go func() {
-				raw[i], err = remoteCache.GetLastValuesFromCache(fullRequestElements[i], nil)
+				defer wg.Done()
+				var err error
+				raw[i], err = remoteCache.GetLastValuesFromCache(ctx, fullRequestElements[i], nil)
+				if err != nil && err != cache.NotCachableError {
+					raw[i], err = writeBackLastValues(ctx, wrapper, remoteCache, fullRequestElements[i], ownerUserIds[i])
+				}
 				if err != nil {
 					m.Lock()
 					defer m.Unlock()
 					dbRequestElements = append(dbRequestElements, fullRequestElements[i])
 					dbRequestIndices = append(dbRequestIndices, i)
+					dbOwnerUserIds = append(dbOwnerUserIds, ownerUserIds[i])
 					if err != cache.NotCachableError {
 						log.Logger.Warn("Could not get data from cache", attributes.ErrorKey, err)
 					}
 				}
-				wg.Done()
 			}()
 		}
 		wg.Wait()
@@ -195,7 +205,7 @@ func lastValueHandler(config configuration.Config, wrapper *timescale.Wrapper, v
 		}
 
 		beforeQueries := time.Now()
-		queries, err := wrapper.GenerateQueries(dbRequestElements, userId, ownerUserIds, "", []models.Device{})
+		queries, err := wrapper.GenerateQueries(ctx, dbRequestElements, userId, dbOwnerUserIds, "", []models.Device{})
 		if err != nil {
 			return nil, http.StatusInternalServerError, err
 		}
@@ -203,7 +213,7 @@ func lastValueHandler(config configuration.Config, wrapper *timescale.Wrapper, v
 			log.Logger.Debug("Query generation took " + time.Since(beforeQueries).String())
 		}
 		beforeQuery := time.Now()
-		data, err := wrapper.ExecuteQueries(queries)
+		data, err := wrapper.ExecuteQueries(ctx, queries)
 		if err != nil {
 			return nil, timescale.GetHTTPErrorCode(err), err
 		}
@@ -220,7 +230,7 @@ func lastValueHandler(config configuration.Config, wrapper *timescale.Wrapper, v
 			timeFormat = time.RFC3339Nano
 		}
 
-		responseRawData, err := formatResponse(remoteCache, model.PerQuery, fullRequestElements, raw, 0, model.Desc, timeFormat, converter)
+		responseRawData, err := formatResponse(ctx, remoteCache, model.PerQuery, fullRequestElements, raw, 0, model.Desc, timeFormat, converter)
 		if err != nil {
 			return nil, http.StatusInternalServerError, err
 		}
@@ -301,3 +311,37 @@ func prepColumns(inserted int, outputIndexToInputIndex map[int]int, v []queriesR
 	}
 	return cols, inserted
 }
+
+// writeBackLastValues reads the last message of a cachable request from the database and stores it in the cache,
+// so following requests for any of its values are served from the cache until the entry expires.
+// ownerUserId has to be the owner of an export.
+func writeBackLastValues(ctx context.Context, wrapper *timescale.Wrapper, remoteCache *cache.RemoteCache, request model.QueriesRequestElement, ownerUserId string) ([][]interface{}, error) {
+	var entry cache.Entry
+	var err error
+	if request.ExportId != nil {
+		entry, err = wrapper.GetLastExportMessage(ctx, *request.ExportId, ownerUserId)
+		if err != nil {
+			return nil, err
+		}
+		err = remoteCache.WriteBackLastExportMessage(ctx, *request.ExportId, entry)
+	} else {
+		var service models.Service
+		service, err = remoteCache.GetService(ctx, *request.ServiceId)
+		if err != nil {
+			return nil, err
+		}
+		entry, err = wrapper.GetLastMessage(ctx, *request.DeviceId, *request.ServiceId, service)
+		if err != nil {
+			return nil, err
+		}
+		err = remoteCache.WriteBackLastMessage(ctx, *request.DeviceId, *request.ServiceId, entry)
+	}
+	if err != nil {
+		log.Logger.Warn("could not write back last message", attributes.ErrorKey, err)
+	}
+	row, err := entry.Row(request.Columns)
+	if err != nil {
+		return nil, err
+	}
+	return [][]interface{}{row}, nil
+}
@@ -24,8 +24,10 @@ import (
 
 	"github.com/SENERGY-Platform/converter/lib/converter"
 	deviceSelection "github.com/SENERGY-Platform/device-selection/pkg/client"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
@@ -47,12 +49,15 @@ func init() {
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      429
+// @Failure      503
 // @Failure      500
 // @Router       /last-message [GET]
 func LastMessageEndpoint(router gin.IRouter, config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, converter *converter.Converter, _ deviceSelection.Client) {
 	router.GET("/last-message", func(c *gin.Context) {
 		writer := c.Writer
 		request := c.Request
+		ctx := request.Context()
 		deviceId := request.URL.Query().Get("device_id")
 		serviceId := request.URL.Query().Get("service_id")
 		if len(deviceId) == 0 {
@@ -64,7 +69,7 @@ func LastMessageEndpoint(router gin.IRouter, config configuration.Config, wrappe
 			return
 		}
 
-		ok, err := verifier.VerifyDevice(deviceId, getToken(request))
+		ok, err := verifier.VerifyDevice(ctx, deviceId, getToken(request))
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrInternalServerError))
 			return
@@ -76,18 +81,22 @@ func LastMessageEndpoint(router gin.IRouter, config configuration.Config, wrappe
 
 		deviceId = strings.Split(deviceId, "$")[0]
 
-		entry, err := remoteCache.GetLastMessageFromCache(deviceId, serviceId)
+		entry, err := remoteCache.GetLastMessageFromCache(ctx, deviceId, serviceId)
 		if err != nil {
-			service, err := remoteCache.GetService(serviceId)
+			service, err := remoteCache.GetService(ctx, serviceId)
 			if err != nil {
 				c.Error(errors.Join(err, model.ErrInternalServerError))
 				return
 			}
-			entry, err = wrapper.GetLastMessage(deviceId, serviceId, service)
+			entry, err = wrapper.GetLastMessage(ctx, deviceId, serviceId, service)
 			if err != nil {
 				c.Error(errors.Join(err, model.ErrInternalServerError))
 				return
 			}
+			err = remoteCache.WriteBackLastMessage(ctx, deviceId, serviceId, entry)
+			if err != nil {
+				log.Logger.Warn("could not write back last message", attributes.ErrorKey, err)
+			}
 		}
 
 		writer.Header().Set("Content-Type", "application/json")
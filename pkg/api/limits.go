@@ -0,0 +1,179 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/limits"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRetryAfter is sent with 429 responses if the time until a retry succeeds is unknown
+const defaultRetryAfter = time.Second
+
+// rateLimit applies the per-user limits of limiter. The user is identified by the subject of the token.
+// Requests exceeding a limit are answered with 429 and Retry-After. Unbounded requests can never pass the cost limit
+// and are answered with 400. The user is attached to the request context, so database work is queued fairly among users.
+func rateLimit(config configuration.Config, limiter *limits.Limiter, remoteCache *cache.RemoteCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := getUserId(c.Request)
+		if err != nil {
+			return // rejected by the endpoints
+		}
+		c.Request = c.Request.WithContext(timescale.WithUser(c.Request.Context(), userId))
+		ok, retryAfter := limiter.Allow(userId)
+		if !ok {
+			tooManyRequests(c, errors.New("request rate limit exceeded"), retryAfter)
+			return
+		}
+		elements, queries, err := estimateRequest(c, remoteCache, userId)
+		if err != nil {
+			// requests that can't be estimated can't be limited either
+			c.String(model.GetStatusCode(err), err.Error())
+			c.Abort()
+			return
+		}
+		cost := limits.EstimateCost(elements)
+		if !limiter.AllowCost(cost) {
+			if math.IsInf(cost, 1) {
+				// retrying can't help, the request has to be bounded
+				c.String(http.StatusBadRequest, errors.Join(errors.New("request is unbounded, set a time range or limit"), model.ErrBadRequest).Error())
+				c.Abort()
+				return
+			}
+			tooManyRequests(c, errors.New("estimated cost of request exceeds limit, reduce the time range or increase groupTime"), defaultRetryAfter)
+			return
+		}
+		ok, release := limiter.AcquireQueries(userId, queries)
+		if !ok {
+			tooManyRequests(c, errors.New("concurrent query limit exceeded"), defaultRetryAfter)
+			return
+		}
+		defer release()
+		if config.RateLimitMaxRows > 0 {
+			c.Request = c.Request.WithContext(timescale.WithRowLimit(c.Request.Context(), config.RateLimitMaxRows))
+		}
+		c.Next()
+		for _, e := range c.Errors {
+			if errors.Is(e.Err, model.ErrTooManyRequests) {
+				c.Header("Retry-After", retryAfterHeader(defaultRetryAfter))
+				break
+			}
+		}
+	}
+}
+
+func tooManyRequests(c *gin.Context, err error, retryAfter time.Duration) {
+	c.Header("Retry-After", retryAfterHeader(retryAfter))
+	c.String(http.StatusTooManyRequests, errors.Join(err, model.ErrTooManyRequests).Error())
+	c.Abort()
+}
+
+func retryAfterHeader(retryAfter time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds()))))
+}
+
+// estimateRequest returns the request elements and the number of queries a request needs. Device group and location
+// elements are expanded to the elements of their devices and services, which are queried separately.
+// The body is read and replaced, so the endpoints can read it again. Requests are parsed like in the endpoints,
+// errors are joined with model.ErrBadRequest or come from the expansion.
+func estimateRequest(c *gin.Context, remoteCache *cache.RemoteCache, userId string) (elements []model.QueriesRequestElement, queries int, err error) {
+	switch c.FullPath() {
+	case "/queries", "/queries/v2":
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, 0, errors.Join(err, model.ErrBadRequest)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		err = json.NewDecoder(bytes.NewReader(body)).Decode(&elements)
+		if err != nil {
+			return nil, 0, errors.Join(err, model.ErrBadRequest)
+		}
+		for _, element := range elements {
+			if !element.Valid() {
+				return nil, 0, errors.Join(errors.New("Invalid request body"), model.ErrBadRequest)
+			}
+		}
+		if c.FullPath() == "/queries" {
+			return elements, len(elements), nil
+		}
+		elements, err = expandSelections(c, remoteCache, userId, elements)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, element := range elements {
+			queries += len(element.Columns) // v2 queries each column separately
+		}
+		return elements, queries, nil
+	case "/download", "/prepare-download":
+		element, err := parseDownloadQuery(c.Request.URL.Query().Get("query"))
+		if err != nil {
+			return nil, 0, err
+		}
+		elements, err = expandSelections(c, remoteCache, userId, []model.QueriesRequestElement{element})
+		if err != nil {
+			return nil, 0, err
+		}
+		if c.FullPath() == "/prepare-download" {
+			return elements, 0, nil // queried later with the secret
+		}
+		return elements, 1, nil // expanded elements are downloaded one after another
+	case "/last-values":
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, 0, errors.Join(err, model.ErrBadRequest)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		var lastValuesElements []model.LastValuesRequestElement
+		err = json.NewDecoder(bytes.NewReader(body)).Decode(&lastValuesElements)
+		if err != nil {
+			return nil, 0, errors.Join(err, model.ErrBadRequest)
+		}
+		return nil, len(lastValuesElements), nil
+	default:
+		return nil, 1, nil
+	}
+}
+
+// expandSelections replaces device group and location elements with the elements of their devices and services.
+// The lookups are cached, so expanding them again in the endpoints is cheap.
+func expandSelections(c *gin.Context, remoteCache *cache.RemoteCache, userId string, elements []model.QueriesRequestElement) (expanded []model.QueriesRequestElement, err error) {
+	for _, element := range elements {
+		if !isSelection(element) {
+			expanded = append(expanded, element)
+			continue
+		}
+		selected, _, err := expandSelection(c.Request.Context(), remoteCache, userId, getToken(c.Request), element)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, selected...)
+	}
+	return expanded, nil
+}
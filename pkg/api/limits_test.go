@@ -0,0 +1,136 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/limits"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+func TestRateLimit(t *testing.T) {
+	server := newTestServer(t, &configuration.ConfigStruct{RateLimitRequestsPerSecond: 0.01, RateLimitBurst: 1})
+	url := server.url + "/last-message?device_id=" + testDeviceId + "&service_id=" + testServiceId
+	resp, _ := server.request(t, http.MethodGet, url, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.StatusCode)
+	}
+	resp, _ = server.request(t, http.MethodGet, url, "")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Error("expected too many requests with retry after", resp.StatusCode, resp.Header)
+	}
+}
+
+func TestCostLimit(t *testing.T) {
+	server := newTestServer(t, &configuration.ConfigStruct{RateLimitMaxCost: 100})
+	for _, tc := range []struct {
+		time       string
+		expected   int
+		retryAfter bool
+	}{
+		// one value per minute is assumed for raw values
+		{time: `"time": {"last": "1d"}, `, expected: http.StatusTooManyRequests, retryAfter: true},
+		{time: ``, expected: http.StatusBadRequest, retryAfter: false},
+	} {
+		body := `[{"deviceId": "` + testDeviceId + `", "serviceId": "` + testServiceId + `", ` + tc.time + `"columns": [{"name": "energy.total"}]}]`
+		resp, _ := server.request(t, http.MethodPost, server.url+"/queries/v2", body)
+		if resp.StatusCode != tc.expected || (resp.Header.Get("Retry-After") != "") != tc.retryAfter {
+			t.Error("unexpected response", body, resp.StatusCode, resp.Header)
+		}
+	}
+}
+
+func TestEstimateRequestExpandsSelections(t *testing.T) {
+	remoteCache := newGroupCache(t, nil)
+
+	var elements []model.QueriesRequestElement
+	var queries int
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.POST("/queries/v2", func(c *gin.Context) {
+		var err error
+		elements, queries, err = estimateRequest(c, remoteCache, "user")
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	body := `[{"deviceGroupId": "group", "time": {"last": "1h"}, "columns": [{"criteria": {"function_id": "function", "aspect_id": "aspect"}}]}]`
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/queries/v2", strings.NewReader(body)))
+	// one element per device and service with a column per path, each column is queried on its own
+	if len(elements) != 2 || queries != 4 {
+		t.Error("expected expanded elements", elements, queries)
+	}
+	if cost := limits.EstimateCost(elements); cost != 4*60 {
+		t.Error("unexpected cost", cost)
+	}
+}
+
+func TestRateLimitRejectsUnestimatedRequests(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user"}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := `[{"deviceGroupId": "group", "time": {"last": "1h"}, "columns": [{"criteria": {"function_id": "function", "aspect_id": "aspect"}}]}]`
+	for _, tc := range []struct {
+		name         string
+		selectionErr error
+		path         string
+		body         string
+		expected     int
+	}{
+		{name: "trailing data is ignored like in the endpoints", path: "/queries/v2", body: group + ` trailing`, expected: http.StatusOK},
+		{name: "malformed body", path: "/queries/v2", body: `[{"deviceGroupId": `, expected: http.StatusBadRequest},
+		{name: "invalid element", path: "/queries/v2", body: `[{"columns": []}]`, expected: http.StatusBadRequest},
+		{name: "malformed last values", path: "/last-values", body: `{`, expected: http.StatusBadRequest},
+		{name: "invalid download query", path: "/download?query=" + url.QueryEscape(`{"columns": []}`), expected: http.StatusBadRequest},
+		{name: "expansion fails", selectionErr: errors.New("device selection unavailable"), path: "/queries/v2", body: group, expected: http.StatusInternalServerError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &configuration.ConfigStruct{}
+			gin.SetMode(gin.ReleaseMode)
+			router := gin.New()
+			router.Use(rateLimit(config, limits.New(config), newGroupCache(t, tc.selectionErr)))
+			reached := false
+			handler := func(c *gin.Context) {
+				reached = true
+			}
+			router.POST("/queries/v2", handler)
+			router.POST("/last-values", handler)
+			router.GET("/download", handler)
+			method := http.MethodPost
+			if tc.body == "" {
+				method = http.MethodGet
+			}
+			request := httptest.NewRequest(method, tc.path, strings.NewReader(tc.body))
+			request.Header.Set("Authorization", "Bearer "+token)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != tc.expected || reached != (tc.expected == http.StatusOK) {
+				t.Error("unexpected response", recorder.Code, reached, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+)
+
+func TestMetrics(t *testing.T) {
+	server := newTestServer(t, &configuration.ConfigStruct{})
+	resp, _ := server.request(t, http.MethodGet, server.url+"/last-message?device_id="+testDeviceId+"&service_id="+testServiceId, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.StatusCode)
+	}
+	resp, body := server.request(t, http.MethodGet, server.unauthenticatedUrl+"/metrics", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.StatusCode)
+	}
+	for _, expected := range []string{
+		`timescale_wrapper_http_request_duration_seconds_count{endpoint="/last-message",method="GET",status="200"}`,
+		`timescale_wrapper_cache_requests_total{cache="last_message",result="hit"}`,
+		`timescale_wrapper_cache_requests_total{cache="local",result="miss"}`,
+		`timescale_wrapper_upstream_request_duration_seconds_count{result="ok",upstream="permissions"}`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Error("missing metric", expected)
+		}
+	}
+}
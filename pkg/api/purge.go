@@ -0,0 +1,104 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/SENERGY-Platform/converter/lib/converter"
+	deviceSelection "github.com/SENERGY-Platform/device-selection/pkg/client"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, PurgeEndpoint)
+}
+
+// Query godoc
+// @Summary      Purge data
+// @Description  Deletes the data of a device, of one device service or of an export, either in the time range start <= time < end or entirely.
+// @Description  Continuous aggregates of the tables are dropped, or refreshed for time ranges. Materialized views are refreshed. Cached last values are removed.
+// @Description  Requires administrate permission, which only owners have.
+// @Accept       json
+// @Produce      json
+// @Security Bearer
+// @Param        payload body model.PurgeRequest true "data to delete"
+// @Success      200 {object} model.PurgeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      429
+// @Failure      503
+// @Failure      500
+// @Router       /purge [DELETE]
+func PurgeEndpoint(router gin.IRouter, _ configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, _ *converter.Converter, _ deviceSelection.Client) {
+	router.DELETE("/purge", func(c *gin.Context) {
+		request := c.Request
+		var purgeRequest model.PurgeRequest
+		err := json.NewDecoder(request.Body).Decode(&purgeRequest)
+		if err != nil {
+			c.Error(errors.Join(err, model.ErrBadRequest))
+			return
+		}
+		if !purgeRequest.Valid() {
+			c.Error(errors.Join(errors.New("Invalid request body"), model.ErrBadRequest))
+			return
+		}
+		userId, err := getUserId(request)
+		if err != nil {
+			c.Error(errors.Join(err, model.ErrBadRequest))
+			return
+		}
+		access, err := verifier.VerifyAdminAccess(request.Context(), purgeRequest.ExportId, purgeRequest.DeviceId, getToken(request), userId)
+		if err != nil {
+			c.Error(errors.Join(err, model.ErrInternalServerError))
+			return
+		}
+		if !access.Ok {
+			c.Error(errors.Join(errors.New("forbidden"), model.ErrForbidden))
+			return
+		}
+		response, err := wrapper.PurgeData(request.Context(), purgeRequest, access.OwnerUserId)
+		if err != nil {
+			c.Error(errors.Join(err, model.GetError(timescale.GetHTTPErrorCode(err))))
+			return
+		}
+		if purgeRequest.ExportId != nil {
+			err = remoteCache.DeleteLastExportMessage(request.Context(), *purgeRequest.ExportId)
+			if err != nil {
+				c.Error(errors.Join(err, model.ErrInternalServerError))
+				return
+			}
+		}
+		for _, serviceId := range response.ServiceIds {
+			err = remoteCache.DeleteLastMessage(request.Context(), *purgeRequest.DeviceId, serviceId)
+			if err != nil {
+				c.Error(errors.Join(err, model.ErrInternalServerError))
+				return
+			}
+		}
+		c.JSON(http.StatusOK, response)
+	})
+}
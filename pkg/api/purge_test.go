@@ -0,0 +1,40 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+)
+
+func TestPurgeValidation(t *testing.T) {
+	server := newTestServer(t, &configuration.ConfigStruct{})
+	for _, tc := range []struct {
+		body     string
+		expected int
+	}{
+		{body: `{"deviceId": "urn:infai:ses:device:denied"}`, expected: http.StatusForbidden},
+		{body: `{"deviceId": "` + testDeviceId + `", "start": "2024-01-01T00:00:00Z"}`, expected: http.StatusBadRequest},
+	} {
+		resp, _ := server.request(t, http.MethodDelete, server.url+"/purge", tc.body)
+		if resp.StatusCode != tc.expected {
+			t.Error("unexpected status", tc.body, resp.StatusCode)
+		}
+	}
+}
@@ -17,6 +17,7 @@
 package api
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -50,7 +51,7 @@ func init() {
 // @Produce      json
 // @Security Bearer
 // @Param        payload body []model.QueriesRequestElement true "requested values"
-// @Param		 format query string false "specifies output format. Use per_query (default) for a 3D array or table for a 2D array with merged timestamps"
+// @Param		 format query string false "specifies output format. Use per_query (default) for a 3D array, table for a 2D array with merged timestamps or arrow for the table as Arrow IPC stream with column types of the database"
 // @Param		 order_column_index query string false "Column to order values by (includes time column). Only works in format table."
 // @Param		 order_direction query string false "Direction to order values by. Allowed are 'asc' and 'desc'. Only works in format table."
 // @Param        time_format query string false "Textual representation of the date 'Mon Jan 2 15:04:05 -0700 MST 2006'. Example: 2006-01-02T15:04:05.000Z07:00 would format timestamps as rfc3339 with ms precision. Find details here: https://golang.org/pkg/time/#Time.Format"
@@ -59,12 +60,15 @@ func init() {
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      429
+// @Failure      503
 // @Failure      500
 // @Router       /queries [POST]
 func QueriesEndpoint(router gin.IRouter, config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, converter *converter.Converter, _ deviceSelection.Client) {
 	router.POST("/queries", func(c *gin.Context) {
 		writer := c.Writer
 		request := c.Request
+		ctx := request.Context()
 		start := time.Now()
 		requestedFormat, orderColumnIndex, orderDirection, err := queriesParseQueryparams(request)
 		if err != nil {
@@ -96,14 +100,14 @@ func QueriesEndpoint(router gin.IRouter, config configuration.Config, wrapper *t
 			return
 		}
 
-		raw, dbRequestElements, dbRequestIndices := queriesGetFromCache(requestElements, remoteCache, config, nil)
+		raw, dbRequestElements, dbRequestIndices := queriesGetFromCache(ctx, requestElements, remoteCache, config, nil)
 
 		ownerUserIds := []string{}
 		for i := range dbRequestElements {
 			ownerUserIds = append(ownerUserIds, ownerUserIdsBefore[dbRequestIndices[i]])
 		}
 		beforeQueries := time.Now()
-		queries, err := wrapper.GenerateQueries(dbRequestElements, userId, ownerUserIds, "", []models.Device{})
+		queries, err := wrapper.GenerateQueries(ctx, dbRequestElements, userId, ownerUserIds, "", []models.Device{})
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrInternalServerError))
 			return
@@ -112,7 +116,7 @@ func QueriesEndpoint(router gin.IRouter, config configuration.Config, wrapper *t
 			log.Logger.Debug("Query generation took " + time.Since(beforeQueries).String())
 		}
 		beforeQuery := time.Now()
-		data, err := wrapper.ExecuteQueries(queries)
+		data, fields, err := wrapper.ExecuteQueriesWithFields(ctx, queries)
 		if err != nil {
 			c.Error(errors.Join(err, model.GetError(timescale.GetHTTPErrorCode(err))))
 			return
@@ -121,12 +125,30 @@ func QueriesEndpoint(router gin.IRouter, config configuration.Config, wrapper *t
 			log.Logger.Debug("Fetching took " + time.Since(beforeQuery).String())
 		}
 		// merge DB results with remoteCache results
+		rawFields := make([][]timescale.Field, len(raw))
 		for i := range data {
 			raw[dbRequestIndices[i]] = data[i]
+			rawFields[dbRequestIndices[i]] = fields[i]
 		}
 		beforePP := time.Now()
 		timeFormat := request.URL.Query().Get("time_format")
-		response, err := formatResponse(remoteCache, requestedFormat, requestElements, raw, orderColumnIndex, orderDirection, timeFormat, converter)
+		if requestedFormat == model.Arrow {
+			columns := tableColumns(requestElements, rawFields, raw)
+			response, err := formatResponse(ctx, remoteCache, model.Table, requestElements, raw, orderColumnIndex, orderDirection, "", converter)
+			if err != nil {
+				c.Error(errors.Join(err, model.ErrInternalServerError))
+				return
+			}
+			err = writeArrow(writer, columns, response.([][]interface{}))
+			if err != nil {
+				fmt.Println("ERROR: " + err.Error())
+			}
+			if config.Debug {
+				log.Logger.Debug("Postprocessing took " + time.Since(beforePP).String())
+			}
+			return
+		}
+		response, err := formatResponse(ctx, remoteCache, requestedFormat, requestElements, raw, orderColumnIndex, orderDirection, timeFormat, converter)
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrInternalServerError))
 			return
@@ -193,7 +215,7 @@ func queriesVerify(requestElements []model.QueriesRequestElement, request *http.
 	if err != nil {
 		return
 	}
-	ok, ownerUserIds, err := verifier.VerifyAccess(requestElements, getToken(request), userId)
+	ok, ownerUserIds, err := verifier.VerifyAccess(request.Context(), requestElements, getToken(request), userId)
 	if err != nil {
 		return
 	}
@@ -208,7 +230,7 @@ func queriesVerify(requestElements []model.QueriesRequestElement, request *http.
 	return
 }
 
-func queriesGetFromCache(requestElements []model.QueriesRequestElement, remoteCache *cache.RemoteCache, config configuration.Config, forceTz *string) (raw [][][]interface{}, dbRequestElements []model.QueriesRequestElement, dbRequestIndices []int) {
+func queriesGetFromCache(ctx context.Context, requestElements []model.QueriesRequestElement, remoteCache *cache.RemoteCache, config configuration.Config, forceTz *string) (raw [][][]interface{}, dbRequestElements []model.QueriesRequestElement, dbRequestIndices []int) {
 	dbRequestElements = []model.QueriesRequestElement{}
 	dbRequestIndices = []int{}
 
@@ -222,7 +244,7 @@ func queriesGetFromCache(requestElements []model.QueriesRequestElement, remoteCa
 		i := i
 		go func() {
 			var err error
-			raw[i], err = remoteCache.GetLastValuesFromCache(requestElements[i], forceTz)
+			raw[i], err = remoteCache.GetLastValuesFromCache(ctx, requestElements[i], forceTz)
 			if err != nil {
 				m.Lock()
 				defer m.Unlock()
@@ -17,6 +17,7 @@
 package api
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -29,49 +30,20 @@ import (
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/meta"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func formatResponse(remoteCache *cache.RemoteCache, f model.Format, request []model.QueriesRequestElement, results [][][]interface{},
+func formatResponse(ctx context.Context, remoteCache *cache.RemoteCache, f model.Format, request []model.QueriesRequestElement, results [][][]interface{},
 	orderColumnIndex int, orderDirection model.Direction, timeFormat string, conv *converter.Converter) (data interface{}, err error) {
 
-	sourceCharacteristicIds := map[int]map[int]*string{}        // seriesIndex to seriesColumnIndex to sourceCharacteristicId
-	extensions := map[int]map[int][]models.ConverterExtension{} // seriesIndex to seriesColumnIndex to ConverterExtensions
-	for seriesIndex := range request {
-		sourceCharacteristicIds[seriesIndex] = make(map[int]*string)
-		extensions[seriesIndex] = make(map[int][]models.ConverterExtension)
-		for seriesColumnIndex := range request[seriesIndex].Columns {
-			if request[seriesIndex].Columns[seriesColumnIndex].TargetCharacteristicId != nil {
-				sourceCharId := request[seriesIndex].Columns[seriesColumnIndex].SourceCharacteristicId
-				if sourceCharId == nil {
-					serviceId := request[seriesIndex].ServiceId
-					if serviceId == nil {
-						return nil, errors.New("service id cant be nil")
-					}
-					service, err := remoteCache.GetService(*serviceId)
-					if err != nil {
-						return nil, err
-					}
-					if len(service.Outputs) != 1 {
-						return nil, errors.New("service doesnt have exactly one output")
-					}
-					pathParts := strings.Split(request[seriesIndex].Columns[seriesColumnIndex].Name, ".")
-					if len(pathParts) > 1 {
-						pathParts = pathParts[1:] // skip root
-					}
-					contentVariable := meta.GetDeepContentVariable(service.Outputs[0].ContentVariable, pathParts)
-					sourceCharId = &contentVariable.CharacteristicId
-				}
-				sourceCharacteristicIds[seriesIndex][seriesColumnIndex] = sourceCharId
-				if request[seriesIndex].Columns[seriesColumnIndex].ConceptId == nil {
-					return nil, errors.New("concept id cant be nil")
-				}
-				concept, err := remoteCache.GetConcept(*request[seriesIndex].Columns[seriesColumnIndex].ConceptId)
-				if err != nil {
-					return nil, err
-				}
-				extensions[seriesIndex][seriesColumnIndex] = concept.Conversions
-			}
-		}
+	ctx, span := tracing.Start(ctx, "formatResponse", attribute.String("format", string(f)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	sourceCharacteristicIds, extensions, err := prepareConversions(ctx, remoteCache, request)
+	if err != nil {
+		return nil, err
 	}
 
 	switch f {
@@ -83,13 +55,17 @@ func formatResponse(remoteCache *cache.RemoteCache, f model.Format, request []mo
 		if len(timeFormat) > 0 {
 			formatTime2D(formatted, timeFormat)
 		}
-		for len(formatted) > 0 && isRowEmpty(formatted[len(formatted)-1]) {
+		gapFilled := false
+		for i := range request {
+			gapFilled = gapFilled || request[i].GapFilled()
+		}
+		for !gapFilled && len(formatted) > 0 && isRowEmpty(formatted[len(formatted)-1]) {
 			formatted = formatted[:len(formatted)-1]
 		}
 		return formatted, nil
 	default:
 		for seriesIndex := range results {
-			for len(results[seriesIndex]) > 0 && isRowEmpty(results[seriesIndex][len(results[seriesIndex])-1]) {
+			for !request[seriesIndex].GapFilled() && len(results[seriesIndex]) > 0 && isRowEmpty(results[seriesIndex][len(results[seriesIndex])-1]) {
 				results[seriesIndex] = results[seriesIndex][:len(results[seriesIndex])-1]
 			}
 			err = model.Sort2D(results[seriesIndex], orderColumnIndex, orderDirection)
@@ -148,6 +124,50 @@ func formatResponse(remoteCache *cache.RemoteCache, f model.Format, request []mo
 	}
 }
 
+// prepareConversions looks up source characteristics and converter extensions for every column that requests a target characteristic
+func prepareConversions(ctx context.Context, remoteCache *cache.RemoteCache, request []model.QueriesRequestElement) (sourceCharacteristicIds map[int]map[int]*string, extensions map[int]map[int][]models.ConverterExtension, err error) {
+	sourceCharacteristicIds = map[int]map[int]*string{}        // seriesIndex to seriesColumnIndex to sourceCharacteristicId
+	extensions = map[int]map[int][]models.ConverterExtension{} // seriesIndex to seriesColumnIndex to ConverterExtensions
+	for seriesIndex := range request {
+		sourceCharacteristicIds[seriesIndex] = make(map[int]*string)
+		extensions[seriesIndex] = make(map[int][]models.ConverterExtension)
+		for seriesColumnIndex := range request[seriesIndex].Columns {
+			if request[seriesIndex].Columns[seriesColumnIndex].TargetCharacteristicId != nil {
+				sourceCharId := request[seriesIndex].Columns[seriesColumnIndex].SourceCharacteristicId
+				if sourceCharId == nil {
+					serviceId := request[seriesIndex].ServiceId
+					if serviceId == nil {
+						return nil, nil, errors.New("service id cant be nil")
+					}
+					service, err := remoteCache.GetService(ctx, *serviceId)
+					if err != nil {
+						return nil, nil, err
+					}
+					if len(service.Outputs) != 1 {
+						return nil, nil, errors.New("service doesnt have exactly one output")
+					}
+					pathParts := strings.Split(request[seriesIndex].Columns[seriesColumnIndex].Name, ".")
+					if len(pathParts) > 1 {
+						pathParts = pathParts[1:] // skip root
+					}
+					contentVariable := meta.GetDeepContentVariable(service.Outputs[0].ContentVariable, pathParts)
+					sourceCharId = &contentVariable.CharacteristicId
+				}
+				sourceCharacteristicIds[seriesIndex][seriesColumnIndex] = sourceCharId
+				if request[seriesIndex].Columns[seriesColumnIndex].ConceptId == nil {
+					return nil, nil, errors.New("concept id cant be nil")
+				}
+				concept, err := remoteCache.GetConcept(ctx, *request[seriesIndex].Columns[seriesColumnIndex].ConceptId)
+				if err != nil {
+					return nil, nil, err
+				}
+				extensions[seriesIndex][seriesColumnIndex] = concept.Conversions
+			}
+		}
+	}
+	return sourceCharacteristicIds, extensions, nil
+}
+
 func formatResponseAsTable(request []model.QueriesRequestElement, data [][][]interface{}, orderColumnIndex int, orderDirection model.Direction, conv *converter.Converter, sourceCharacteristicIds map[int]map[int]*string, extensions map[int]map[int][]models.ConverterExtension) (formatted [][]interface{}, err error) {
 	totalColumns := 1
 	baseIndex := map[int]int{}
@@ -214,7 +234,7 @@ func formatResponseAsTable(request []model.QueriesRequestElement, data [][][]int
 					continue
 				}
 			}
-			if anyData {
+			if anyData || request[seriesIndex].GapFilled() { // filled series keep a row per time bucket
 				formatted = append(formatted, formattedRow)
 			}
 		}
@@ -269,13 +289,9 @@ func writeCsv(data interface{}, writer *csv.Writer) (err error) {
 	if ok {
 		for i := range data3D {
 			for j := range data3D[i] {
-				vals := make([]string, len(data3D[i][j]))
-				for k := range data3D[i][j] {
-					b, err := json.Marshal(data3D[i][j][k])
-					if err != nil {
-						return err
-					}
-					vals[k] = strings.Replace(string(b), "\"", "", -1)
+				vals, err := csvRecord(data3D[i][j])
+				if err != nil {
+					return err
 				}
 				err = writer.Write(vals)
 				if err != nil {
@@ -291,13 +307,9 @@ func writeCsv(data interface{}, writer *csv.Writer) (err error) {
 		return errors.New("data is not 2d or 3d")
 	}
 	for i := range data2D {
-		vals := make([]string, len(data2D[i]))
-		for j := range data2D[i] {
-			b, err := json.Marshal(data2D[i][j])
-			if err != nil {
-				return err
-			}
-			vals[j] = strings.Replace(string(b), "\"", "", -1)
+		vals, err := csvRecord(data2D[i])
+		if err != nil {
+			return err
 		}
 		err = writer.Write(vals)
 		if err != nil {
@@ -307,3 +319,15 @@ func writeCsv(data interface{}, writer *csv.Writer) (err error) {
 	writer.Flush()
 	return nil
 }
+
+func csvRecord(row []interface{}) (vals []string, err error) {
+	vals = make([]string, len(row))
+	for j := range row {
+		b, err := json.Marshal(row[j])
+		if err != nil {
+			return nil, err
+		}
+		vals[j] = strings.Replace(string(b), "\"", "", -1)
+	}
+	return vals, nil
+}
@@ -17,6 +17,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,6 +31,7 @@ import (
 	deviceSelection "github.com/SENERGY-Platform/device-selection/pkg/client"
 	"github.com/SENERGY-Platform/models/go/models"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/columnar"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
@@ -48,31 +50,39 @@ func init() {
 // @Produce      json
 // @Security Bearer
 // @Param        payload body []model.QueriesRequestElement true "requested values"
-// @Param		 format query string false "specifies output format. Use per_query (default) for a 3D array or table for a 2D array with merged timestamps"
+// @Param		 format query string false "specifies output format. Use arrow for an Arrow IPC stream with one row per value and the columns request_index, device_id, service_id, export_id, column, time and value. Column types are taken from the database. Can't be combined with stream. JSON is returned otherwise."
 // @Param		 order_column_index query string false "Column to order values by (includes time column). Only works in format table."
 // @Param		 order_direction query string false "Direction to order values by. Allowed are 'asc' and 'desc'. Only works in format table."
 // @Param        time_format query string false "Textual representation of the date 'Mon Jan 2 15:04:05 -0700 MST 2006'. Example: 2006-01-02T15:04:05.000Z07:00 would format timestamps as rfc3339 with ms precision. Find details here: https://golang.org/pkg/time/#Time.Format"
 // @Param		 locate_lat query string false "Used to automatically select the clostest location on a multivalued import export. Only works with exportId set to an export of an import. User needs read access to the import type."
 // @Param		 locate_lon query string false "Used to automatically select the clostest location on a multivalued import export. Only works with exportId set to an export of an import. User needs read access to the import type."
 // @Param		 force_tz query string false "Calculate aggregations with the specified timezone instead of the default device timezone. Might increase calculation complexity and response time."
+// @Param		 stream query bool false "Write each response element as soon as its queries finish and read rows from the database incrementally. Intended for large results. Errors after the first byte was written truncate the JSON array and are reported in the X-Stream-Error trailer."
+// @Param		 dry_run query bool false "Do not execute the queries. Instead, returns an array of model.QueriesV2DryRunResponseElement with the generated SQL, the selected table or continuous aggregate and the EXPLAIN cost estimate of each query. Bypasses the last-values cache."
 // @Success      200 {array} model.QueriesV2ResponseElement "requestIndex allows to match response and request elements (topmost array in request). If a device group is requested, each device will return its own time series. If multiple columns are requested, each will be return as a time series within the data field. If a criteria is selected and multiple paths match the criteria, all matching values will be part of the time series."
 // @Failure      400
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      429
+// @Failure      503
 // @Failure      500
 // @Router       /queries/v2 [POST]
 func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, remoteCache *cache.RemoteCache, converter *converter.Converter, deviceSelectionClient deviceSelection.Client) {
 	router.POST("/queries/v2", func(c *gin.Context) {
 		writer := c.Writer
 		request := c.Request
+		ctx := request.Context()
 		start := time.Now()
-		_, _, _, err := queriesParseQueryparams(request)
+		requestedFormat, _, _, err := queriesParseQueryparams(request)
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrBadRequest))
 			return
 		}
 		timeFormat := request.URL.Query().Get("time_format")
+		if requestedFormat == model.Arrow {
+			timeFormat = "" // arrow has native timestamps
+		}
 
 		var requestElements []model.QueriesRequestElement
 		err = json.NewDecoder(request.Body).Decode(&requestElements)
@@ -98,9 +108,58 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 		if len(forceTz) > 0 {
 			forceTzp = &forceTz
 		}
+		stream := false
+		if streamParam := request.URL.Query().Get("stream"); len(streamParam) > 0 {
+			stream, err = strconv.ParseBool(streamParam)
+			if err != nil {
+				c.Error(errors.Join(err, model.ErrBadRequest))
+				return
+			}
+		}
+		if stream && requestedFormat == model.Arrow {
+			c.Error(errors.Join(errors.New("stream can't be used with format arrow"), model.ErrBadRequest))
+			return
+		}
+		dryRun := false
+		if dryRunParam := request.URL.Query().Get("dry_run"); len(dryRunParam) > 0 {
+			dryRun, err = strconv.ParseBool(dryRunParam)
+			if err != nil {
+				c.Error(errors.Join(err, model.ErrBadRequest))
+				return
+			}
+		}
 
-		raw, dbRequestElementsBefore, dbRequestIndices := queriesGetFromCache(requestElements, remoteCache, config, forceTzp)
+		var raw [][][]interface{}
+		var dbRequestElementsBefore []model.QueriesRequestElement
+		var dbRequestIndices []int
+		if dryRun {
+			// every element has to go through query generation to be explained
+			dbRequestElementsBefore = requestElements
+			for i := range requestElements {
+				dbRequestIndices = append(dbRequestIndices, i)
+			}
+		} else {
+			raw, dbRequestElementsBefore, dbRequestIndices = queriesGetFromCache(ctx, requestElements, remoteCache, config, forceTzp)
+		}
 		response := []model.QueriesV2ResponseElement{}
+		dryRunResponse := []model.QueriesV2DryRunResponseElement{}
+		streamElements := []queriesV2StreamElement{}
+		arrowRows := [][]interface{}{}
+		arrowValueTypes := []columnar.Type{}
+		if requestedFormat == model.Arrow {
+			for i, r := range raw {
+				for _, row := range r {
+					for k := 1; k < len(row) && k <= len(requestElements[i].Columns); k++ {
+						if row[k] == nil {
+							continue
+						}
+						arrowRows = append(arrowRows, []interface{}{int64(i), stringOrNil(requestElements[i].DeviceId), stringOrNil(requestElements[i].ServiceId),
+							stringOrNil(requestElements[i].ExportId), requestElements[i].Columns[k-1].Name, row[0], row[k]})
+						arrowValueTypes = append(arrowValueTypes, columnType(requestElements[i].Columns[k-1], nil, k, [][]interface{}{row}))
+					}
+				}
+			}
+		}
 		for i, r := range raw {
 			if len(r) != 0 {
 				response = append(response, model.QueriesV2ResponseElement{
@@ -145,11 +204,7 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 			wg.Add(1)
 			i := i
 			dbRequestElement := dbRequestElement
-			columnMatch := map[int]struct {
-				selIdx int
-				colIdx int
-				elem   *model.QueriesRequestElement
-			}{}
+			columnMatch := map[int]queriesV2ColumnMatch{}
 			go func() {
 				defer wg.Done()
 				dbRequestElements := []model.QueriesRequestElement{}
@@ -161,7 +216,7 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 						}
 						if dbRequestElement.ExportId != nil && len(locateLat) > 0 && len(locateLon) > 0 {
 							token := getToken(request)
-							importFilters, err := wrapper.CreateFiltersForImport(*dbRequestElement.ExportId, userId, token, locateLatFloat, locateLonFloat)
+							importFilters, err := wrapper.CreateFiltersForImport(ctx, *dbRequestElement.ExportId, userId, token, locateLatFloat, locateLonFloat)
 							if err != nil {
 								raiseError(errors.Join(err, model.ErrBadRequest))
 								return
@@ -170,7 +225,7 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 						}
 
 						if dbRequestElement.DeviceId != nil {
-							device, err := remoteCache.GetDevice(*dbRequestElement.DeviceId, token)
+							device, err := remoteCache.GetDevice(ctx, *dbRequestElement.DeviceId, token)
 							if err != nil {
 								raiseError(errors.Join(err, model.ErrInternalServerError))
 								return
@@ -180,23 +235,8 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 							mux.Unlock()
 						}
 
-						elem := model.QueriesRequestElement{
-							ExportId:         dbRequestElement.ExportId,
-							DeviceId:         dbRequestElement.DeviceId,
-							ServiceId:        dbRequestElement.ServiceId,
-							Time:             dbRequestElement.Time,
-							Limit:            dbRequestElement.Limit,
-							Columns:          []model.QueriesRequestElementColumn{col},
-							Filters:          &filters,
-							GroupTime:        dbRequestElement.GroupTime,
-							OrderColumnIndex: dbRequestElement.OrderColumnIndex,
-							OrderDirection:   dbRequestElement.OrderDirection,
-						}
-						columnMatch[len(dbRequestElements)] = struct {
-							selIdx int
-							colIdx int
-							elem   *model.QueriesRequestElement
-						}{
+						elem := dbRequestElement.ColumnElement(col, &filters)
+						columnMatch[len(dbRequestElements)] = queriesV2ColumnMatch{
 							selIdx: 0,
 							colIdx: colIdx,
 							elem:   &elem,
@@ -207,95 +247,21 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 						mux.Unlock()
 					}
 				} else {
-					deviceGroupIds := []string{}
-					deviceIds := []string{}
-					if dbRequestElement.DeviceGroupId != nil {
-						deviceGroupIds = append(deviceGroupIds, *dbRequestElement.DeviceGroupId)
+					elems, matches, err := expandSelection(ctx, remoteCache, userId, token, dbRequestElement)
+					if err != nil {
+						raiseError(err)
+						return
 					}
-					if dbRequestElement.LocationId != nil {
-						location, err := remoteCache.GetLocation(*dbRequestElement.LocationId, token)
-						if err != nil {
-							raiseError(errors.Join(err, model.ErrInternalServerError))
-							return
-						}
-						deviceIds = append(deviceIds, location.DeviceIds...)
-						deviceGroupIds = append(deviceGroupIds, location.DeviceGroupIds...)
-					}
-
-					for _, deviceGroupid := range deviceGroupIds {
-						deviceGroup, err := remoteCache.GetDeviceGroup(deviceGroupid, token)
-						if err != nil {
-							raiseError(errors.Join(err, model.ErrInternalServerError))
-							return
-						}
-						deviceIds = append(deviceIds, deviceGroup.DeviceIds...)
-					}
-					for colIdx, col := range dbRequestElement.Columns {
-						f, err := remoteCache.GetFunction(col.Criteria.FunctionId)
-						if err != nil {
-							raiseError(errors.Join(err, model.GetError(code)))
-							return
-						}
-
-						criteria := []models.DeviceGroupFilterCriteria{col.Criteria}
-
-						selectables, code, err := remoteCache.GetSelectables(userId, token, criteria, &deviceSelection.GetSelectablesOptions{
-							IncludeDevices:    true,
-							WithDeviceIds:     deviceIds,
-							IncludeIdModified: true,
-						})
-						if err != nil {
-							raiseError(errors.Join(err, model.GetError(code)))
-							return
-						}
-						for selIdx, selectable := range selectables {
-							if !slices.Contains(deviceIds, selectable.Device.Id) {
-								continue //ensures only correctly modified device ids included
-							}
-							pureDeviceId, _ := idmodifier.SplitModifier(selectable.Device.Id)
-							for serviceId, paths := range selectable.ServicePathOptions {
-								serviceId := serviceId
-								columns := []model.QueriesRequestElementColumn{}
-								for _, path := range paths {
-									columns = append(columns, model.QueriesRequestElementColumn{
-										Name:                   path.Path,
-										GroupType:              col.GroupType,
-										Math:                   col.Math,
-										SourceCharacteristicId: &path.CharacteristicId,
-										TargetCharacteristicId: col.TargetCharacteristicId,
-										ConceptId:              &f.ConceptId,
-									})
-								}
-								elem := model.QueriesRequestElement{
-									DeviceId:         &pureDeviceId,
-									ServiceId:        &serviceId,
-									Time:             dbRequestElement.Time,
-									Limit:            dbRequestElement.Limit,
-									Columns:          columns,
-									Filters:          dbRequestElement.Filters,
-									GroupTime:        dbRequestElement.GroupTime,
-									OrderColumnIndex: dbRequestElement.OrderColumnIndex,
-									OrderDirection:   dbRequestElement.OrderDirection,
-								}
-								columnMatch[len(dbRequestElements)] = struct {
-									selIdx int
-									colIdx int
-									elem   *model.QueriesRequestElement
-								}{
-									selIdx: selIdx,
-									colIdx: colIdx,
-									elem:   &elem,
-								}
-								mux.Lock()
-								dbRequestElements = append(dbRequestElements, elem)
-								ownerUserIds = append(ownerUserIds, ownerUserIdsBefore[dbRequestIndices[i]])
-								mux.Unlock()
-							}
-						}
+					for k := range elems {
+						columnMatch[len(dbRequestElements)] = matches[k]
+						mux.Lock()
+						dbRequestElements = append(dbRequestElements, elems[k])
+						ownerUserIds = append(ownerUserIds, ownerUserIdsBefore[dbRequestIndices[i]])
+						mux.Unlock()
 					}
 				}
 
-				queries, err := wrapper.GenerateQueries(dbRequestElements, userId, ownerUserIds, forceTz, devices)
+				queries, err := wrapper.GenerateQueries(ctx, dbRequestElements, userId, ownerUserIds, forceTz, devices)
 				if err != nil {
 					raiseError(errors.Join(err, model.ErrInternalServerError))
 					return
@@ -303,8 +269,48 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 				if config.Debug {
 					log.Logger.Debug("Query generation took " + time.Since(beforeQueries).String())
 				}
+				if dryRun {
+					plans, err := wrapper.ExplainQueries(ctx, queries)
+					if err != nil {
+						raiseError(errors.Join(err, model.GetError(timescale.GetHTTPErrorCode(err))))
+						return
+					}
+					mux.Lock()
+					defer mux.Unlock()
+					for j, query := range queries {
+						columnNames := []string{}
+						for _, col := range dbRequestElements[j].Columns {
+							columnNames = append(columnNames, col.Name)
+						}
+						dryRunResponse = append(dryRunResponse, model.QueriesV2DryRunResponseElement{
+							RequestIndex:        dbRequestIndices[i],
+							DeviceId:            dbRequestElements[j].DeviceId,
+							ServiceId:           dbRequestElements[j].ServiceId,
+							ExportId:            dbRequestElements[j].ExportId,
+							ColumnNames:         columnNames,
+							Sql:                 query.Sql,
+							Args:                query.Args,
+							Table:               query.Table,
+							ContinuousAggregate: query.ContinuousAggregate,
+							Plan:                plans[j],
+						})
+					}
+					return
+				}
+				if stream {
+					mux.Lock()
+					defer mux.Unlock()
+					streamElements = append(streamElements, queriesV2StreamElement{
+						requestIndex:      dbRequestIndices[i],
+						requestElement:    dbRequestElement,
+						dbRequestElements: dbRequestElements,
+						queries:           queries,
+						columnMatch:       columnMatch,
+					})
+					return
+				}
 				beforeQuery := time.Now()
-				data, err := wrapper.ExecuteQueries(queries)
+				data, fields, err := wrapper.ExecuteQueriesWithFields(ctx, queries)
 				if err != nil {
 					raiseError(errors.Join(err, model.GetError(timescale.GetHTTPErrorCode(err))))
 					return
@@ -312,16 +318,8 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 				if config.Debug {
 					log.Logger.Debug("Fetching took " + time.Since(beforeQuery).String())
 				}
-				orderColumnIndex := 0
-				if dbRequestElement.OrderColumnIndex != nil {
-					orderColumnIndex = *dbRequestElement.OrderColumnIndex
-				}
-				orderDirection := model.Asc
-				if dbRequestElement.OrderDirection != nil {
-					orderDirection = *dbRequestElement.OrderDirection
-				}
-
-				subResponse, err := formatResponse(remoteCache, model.PerQuery, dbRequestElements, data, orderColumnIndex, orderDirection, timeFormat, converter)
+				orderColumnIndex, orderDirection := responseOrder(dbRequestElement)
+				subResponse, err := formatResponse(ctx, remoteCache, model.PerQuery, dbRequestElements, data, orderColumnIndex, orderDirection, timeFormat, converter)
 				if err != nil {
 					raiseError(errors.Join(err, model.ErrInternalServerError))
 					return
@@ -331,6 +329,26 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 					raiseError(errors.Join(errors.New("could not cast subresponse"), model.ErrInternalServerError))
 					return
 				}
+				if requestedFormat == model.Arrow {
+					mux.Lock()
+					defer mux.Unlock()
+					for j := range subResponseCasted {
+						elem := dbRequestElements[j]
+						for k := 1; k <= len(elem.Columns); k++ {
+							arrowValueTypes = append(arrowValueTypes, columnType(elem.Columns[k-1], fields[j], k, nil))
+						}
+						for _, row := range subResponseCasted[j] {
+							for k := 1; k < len(row) && k <= len(elem.Columns); k++ {
+								if row[k] == nil {
+									continue
+								}
+								arrowRows = append(arrowRows, []interface{}{int64(dbRequestIndices[i]), stringOrNil(elem.DeviceId), stringOrNil(elem.ServiceId),
+									stringOrNil(elem.ExportId), elem.Columns[k-1].Name, row[0], row[k]})
+							}
+						}
+					}
+					return
+				}
 
 				// merge DB results with remoteCache results
 				mux.Lock()
@@ -392,7 +410,41 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 			return
 		}
 
+		if requestedFormat == model.Arrow && !dryRun {
+			err = writeArrow(writer, []columnar.Column{
+				{Name: "request_index", Type: columnar.Int64},
+				{Name: "device_id", Type: columnar.String},
+				{Name: "service_id", Type: columnar.String},
+				{Name: "export_id", Type: columnar.String},
+				{Name: "column", Type: columnar.String},
+				{Name: "time", Type: columnar.Timestamp},
+				{Name: "value", Type: columnar.Common(arrowValueTypes...)},
+			}, arrowRows)
+			if err != nil {
+				fmt.Println("ERROR: " + err.Error())
+			}
+			return
+		}
+
+		if stream && !dryRun {
+			slices.SortStableFunc(streamElements, func(a, b queriesV2StreamElement) int {
+				return a.requestIndex - b.requestIndex
+			})
+			streamQueriesV2(ctx, writer, config, wrapper, remoteCache, converter, response, streamElements, timeFormat)
+			return
+		}
+
 		writer.Header().Set("Content-Type", "application/json")
+		if dryRun {
+			slices.SortStableFunc(dryRunResponse, func(a, b model.QueriesV2DryRunResponseElement) int {
+				return a.RequestIndex - b.RequestIndex
+			})
+			err = json.NewEncoder(writer).Encode(dryRunResponse)
+			if err != nil {
+				fmt.Println("ERROR: " + err.Error())
+			}
+			return
+		}
 		err = json.NewEncoder(writer).Encode(response)
 		if err != nil {
 			fmt.Println("ERROR: " + err.Error())
@@ -400,3 +452,80 @@ func QueriesV2Endpoint(router gin.IRouter, config configuration.Config, wrapper
 	})
 
 }
+
+// expandSelection resolves the devices of a device group or location request element.
+// Returns one request element per device and service that matches the criteria of a requested column.
+// responseOrder returns the column index and direction the rows of each series of element are sorted by
+func responseOrder(element model.QueriesRequestElement) (orderColumnIndex int, orderDirection model.Direction) {
+	orderDirection = model.Asc
+	if element.OrderColumnIndex != nil {
+		orderColumnIndex = *element.OrderColumnIndex
+	}
+	if element.OrderDirection != nil {
+		orderDirection = *element.OrderDirection
+	}
+	return orderColumnIndex, orderDirection
+}
+
+func expandSelection(ctx context.Context, remoteCache *cache.RemoteCache, userId string, token string, element model.QueriesRequestElement) (elements []model.QueriesRequestElement, matches []queriesV2ColumnMatch, err error) {
+	deviceGroupIds := []string{}
+	deviceIds := []string{}
+	if element.DeviceGroupId != nil {
+		deviceGroupIds = append(deviceGroupIds, *element.DeviceGroupId)
+	}
+	if element.LocationId != nil {
+		location, err := remoteCache.GetLocation(ctx, *element.LocationId, token)
+		if err != nil {
+			return nil, nil, errors.Join(err, model.ErrInternalServerError)
+		}
+		deviceIds = append(deviceIds, location.DeviceIds...)
+		deviceGroupIds = append(deviceGroupIds, location.DeviceGroupIds...)
+	}
+
+	for _, deviceGroupid := range deviceGroupIds {
+		deviceGroup, err := remoteCache.GetDeviceGroup(ctx, deviceGroupid, token)
+		if err != nil {
+			return nil, nil, errors.Join(err, model.ErrInternalServerError)
+		}
+		deviceIds = append(deviceIds, deviceGroup.DeviceIds...)
+	}
+	for colIdx, col := range element.Columns {
+		f, err := remoteCache.GetFunction(ctx, col.Criteria.FunctionId)
+		if err != nil {
+			return nil, nil, errors.Join(err, model.ErrInternalServerError)
+		}
+
+		criteria := []models.DeviceGroupFilterCriteria{col.Criteria}
+
+		selectables, code, err := remoteCache.GetSelectables(ctx, userId, token, criteria, &deviceSelection.GetSelectablesOptions{
+			IncludeDevices:    true,
+			WithDeviceIds:     deviceIds,
+			IncludeIdModified: true,
+		})
+		if err != nil {
+			return nil, nil, errors.Join(err, model.GetError(code))
+		}
+		for selIdx, selectable := range selectables {
+			if !slices.Contains(deviceIds, selectable.Device.Id) {
+				continue //ensures only correctly modified device ids included
+			}
+			pureDeviceId, _ := idmodifier.SplitModifier(selectable.Device.Id)
+			for serviceId, paths := range selectable.ServicePathOptions {
+				serviceId := serviceId
+				columns := []model.QueriesRequestElementColumn{}
+				for _, path := range paths {
+					columns = append(columns, col.ExpandedColumn(path.Path, path.CharacteristicId, f.ConceptId))
+				}
+				elements = append(elements, element.ExpandedElement(pureDeviceId, serviceId, columns))
+				matches = append(matches, queriesV2ColumnMatch{
+					selIdx: selIdx,
+					colIdx: colIdx,
+				})
+			}
+		}
+	}
+	for k := range matches {
+		matches[k].elem = &elements[k]
+	}
+	return elements, matches, nil
+}
@@ -0,0 +1,362 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/converter/lib/converter"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+)
+
+const streamErrorTrailer = "X-Stream-Error"
+
+// streamFlushRows is the number of rows written between flushes. Each flush grants another apiWriteTimeout,
+// so clients that stop reading release the database connection of the streamed query after at most apiWriteTimeout.
+const streamFlushRows = 1000
+
+type queriesV2ColumnMatch struct {
+	selIdx int
+	colIdx int
+	elem   *model.QueriesRequestElement
+}
+
+// queriesV2StreamElement holds the generated queries of one request element until they are streamed
+type queriesV2StreamElement struct {
+	requestIndex      int
+	requestElement    model.QueriesRequestElement
+	dbRequestElements []model.QueriesRequestElement
+	queries           []timescale.Query
+	columnMatch       map[int]queriesV2ColumnMatch
+}
+
+// streamQueriesV2 writes the response of /queries/v2 as JSON array, one response element at a time.
+// Response elements are built the same way QueriesV2Endpoint merges them, but rows are written as they are read from the database.
+func streamQueriesV2(ctx context.Context, writer http.ResponseWriter, config configuration.Config, wrapper *timescale.Wrapper, remoteCache *cache.RemoteCache,
+	conv *converter.Converter, cached []model.QueriesV2ResponseElement, elements []queriesV2StreamElement, timeFormat string) {
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Trailer", streamErrorTrailer)
+	writer.WriteHeader(http.StatusOK)
+	flush := func() error {
+		return flushStream(writer, apiWriteTimeout)
+	}
+
+	err := func() error {
+		_, err := writer.Write([]byte("["))
+		if err != nil {
+			return err
+		}
+		first := true
+		next := func() error {
+			if first {
+				first = false
+				return nil
+			}
+			_, err := writer.Write([]byte(","))
+			return err
+		}
+		for _, element := range cached {
+			b, err := json.Marshal(element)
+			if err != nil {
+				return err
+			}
+			err = next()
+			if err != nil {
+				return err
+			}
+			_, err = writer.Write(b)
+			if err != nil {
+				return err
+			}
+		}
+		err = flush()
+		if err != nil {
+			return err
+		}
+		for _, element := range elements {
+			beforeQuery := time.Now()
+			for _, responseElement := range element.responseElements() {
+				err = next()
+				if err != nil {
+					return err
+				}
+				err = streamQueriesV2ResponseElement(ctx, writer, flush, wrapper, remoteCache, conv, element, responseElement, timeFormat)
+				if err != nil {
+					return err
+				}
+				err = flush()
+				if err != nil {
+					return err
+				}
+			}
+			if config.Debug {
+				log.Logger.Debug("Streaming took " + time.Since(beforeQuery).String())
+			}
+		}
+		_, err = writer.Write([]byte("]"))
+		return err
+	}()
+	if err != nil {
+		// the status code is already sent, the client has to detect the truncated array
+		log.Logger.Error("could not stream response", attributes.ErrorKey, err)
+		writer.Header().Set(streamErrorTrailer, err.Error())
+		return
+	}
+	_ = flush()
+}
+
+// flushStream sends buffered data to the client and grants another writeTimeout, long running responses would fail otherwise
+func flushStream(writer http.ResponseWriter, writeTimeout time.Duration) error {
+	controller := http.NewResponseController(writer)
+	err := controller.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	err = controller.Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// queriesV2StreamResponseElement maps the columns of one response element to the queries delivering them
+type queriesV2StreamResponseElement struct {
+	model.QueriesV2ResponseElement
+	queryIndices []int // colIdx to index in queries, -1 if no query delivers the column
+}
+
+func (element queriesV2StreamElement) responseElements() (result []queriesV2StreamResponseElement) {
+	if len(element.columnMatch) == 0 {
+		columnNames := []string{}
+		for _, col := range element.requestElement.Columns {
+			columnNames = append(columnNames, col.Name)
+		}
+		for j := range element.queries {
+			result = append(result, queriesV2StreamResponseElement{
+				QueriesV2ResponseElement: model.QueriesV2ResponseElement{
+					RequestIndex: element.requestIndex,
+					DeviceId:     element.requestElement.DeviceId,
+					ServiceId:    element.requestElement.ServiceId,
+					ExportId:     element.requestElement.ExportId,
+					ColumnNames:  columnNames,
+				},
+				queryIndices: []int{j},
+			})
+		}
+		return result
+	}
+	selIdxToResult := map[int]int{}
+	for j := range element.queries {
+		match := element.columnMatch[j]
+		resultIdx, ok := selIdxToResult[match.selIdx]
+		if !ok {
+			columnNames := []string{}
+			for _, col := range match.elem.Columns {
+				columnNames = append(columnNames, col.Name)
+			}
+			resultIdx = len(result)
+			selIdxToResult[match.selIdx] = resultIdx
+			result = append(result, queriesV2StreamResponseElement{
+				QueriesV2ResponseElement: model.QueriesV2ResponseElement{
+					RequestIndex: element.requestIndex,
+					SelIdx:       match.selIdx,
+					DeviceId:     match.elem.DeviceId,
+					ServiceId:    match.elem.ServiceId,
+					ExportId:     match.elem.ExportId,
+					ColumnNames:  columnNames,
+				},
+			})
+		}
+		for len(result[resultIdx].queryIndices) <= match.colIdx {
+			result[resultIdx].queryIndices = append(result[resultIdx].queryIndices, -1)
+		}
+		result[resultIdx].queryIndices[match.colIdx] = j // later queries replace earlier ones, as in the buffered response
+	}
+	return result
+}
+
+func streamQueriesV2ResponseElement(ctx context.Context, writer io.Writer, flush func() error, wrapper *timescale.Wrapper, remoteCache *cache.RemoteCache, conv *converter.Converter,
+	element queriesV2StreamElement, responseElement queriesV2StreamResponseElement, timeFormat string) error {
+
+	responseElement.Data = [][][]interface{}{}
+	b, err := json.Marshal(responseElement.QueriesV2ResponseElement)
+	if err != nil {
+		return err
+	}
+	prefix, suffix, ok := bytes.Cut(b, []byte(`"data":[]`))
+	if !ok {
+		return errors.New("unexpected response element encoding")
+	}
+	_, err = writer.Write(append(prefix, []byte(`"data":[`)...))
+	if err != nil {
+		return err
+	}
+	for colIdx, queryIndex := range responseElement.queryIndices {
+		if colIdx > 0 {
+			_, err = writer.Write([]byte(","))
+			if err != nil {
+				return err
+			}
+		}
+		_, err = writer.Write([]byte("["))
+		if err != nil {
+			return err
+		}
+		if queryIndex >= 0 {
+			// rows can't be sorted after reading them, the database has to deliver them in the order of the buffered response
+			orderColumnIndex, orderDirection := responseOrder(element.requestElement)
+			request := element.dbRequestElements[queryIndex]
+			query := timescale.OrderedQuery(element.queries[queryIndex], orderColumnIndex, orderDirection, request.Limit)
+			err = streamQueriesV2Series(ctx, writer, flush, wrapper, remoteCache, conv, request, query, timeFormat)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = writer.Write([]byte("]"))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = writer.Write(append([]byte("]"), suffix...))
+	return err
+}
+
+// streamQueriesV2Series writes the rows of a single query as comma separated JSON arrays.
+// Applies the post-processing of formatResponse row by row. Sorting and limits are left to the query.
+func streamQueriesV2Series(ctx context.Context, writer io.Writer, flush func() error, wrapper *timescale.Wrapper, remoteCache *cache.RemoteCache, conv *converter.Converter,
+	request model.QueriesRequestElement, query timescale.Query, timeFormat string) error {
+
+	sourceCharacteristicIds, extensions, err := prepareConversions(ctx, remoteCache, []model.QueriesRequestElement{request})
+	if err != nil {
+		return err
+	}
+	var end *time.Time
+	if request.Time != nil && (request.Time.EndOriginal != nil || request.Time.End != nil) {
+		var ts time.Time
+		if request.Time.EndOriginal != nil {
+			ts, err = time.Parse(time.RFC3339, *request.Time.EndOriginal)
+		} else {
+			ts, err = time.Parse(time.RFC3339, *request.Time.End)
+		}
+		if err != nil {
+			return err
+		}
+		end = &ts
+	}
+	read := func(handle func(row []interface{}) error) error {
+		_, err := wrapper.StreamQuery(ctx, query.Sql, query.Args, handle)
+		return err
+	}
+	return writeSeries(writer, flush, request, read, end, conv, sourceCharacteristicIds[0], extensions[0], timeFormat)
+}
+
+// writeSeries writes the rows passed on by read as comma separated JSON arrays and flushes every streamFlushRows rows.
+// Like formatResponse trims them, empty rows are only written once a row with values follows.
+func writeSeries(writer io.Writer, flush func() error, request model.QueriesRequestElement, read func(handle func(row []interface{}) error) error, end *time.Time,
+	conv *converter.Converter, sourceCharacteristicIds map[int]*string, extensions map[int][]models.ConverterExtension, timeFormat string) error {
+
+	first := true
+	written := 0
+	write := func(row []interface{}) error {
+		keep, err := formatRow(request, row, end, conv, sourceCharacteristicIds, extensions, timeFormat)
+		if err != nil || !keep {
+			return err
+		}
+		written++
+		if written%streamFlushRows == 0 {
+			err = flush()
+			if err != nil {
+				return err
+			}
+		}
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if !first {
+			_, err = writer.Write([]byte(","))
+			if err != nil {
+				return err
+			}
+		}
+		first = false
+		_, err = writer.Write(b)
+		return err
+	}
+	var empty [][]interface{}
+	return read(func(row []interface{}) error {
+		if isRowEmpty(row) && !request.GapFilled() {
+			empty = append(empty, row)
+			return nil
+		}
+		for _, emptyRow := range empty {
+			err := write(emptyRow)
+			if err != nil {
+				return err
+			}
+		}
+		empty = empty[:0]
+		return write(row)
+	})
+}
+
+// formatRow converts a single row in place. Returns false if the row should be dropped.
+func formatRow(request model.QueriesRequestElement, row []interface{}, end *time.Time, conv *converter.Converter,
+	sourceCharacteristicIds map[int]*string, extensions map[int][]models.ConverterExtension, timeFormat string) (keep bool, err error) {
+
+	if end != nil && len(row) > 0 && row[0] != nil {
+		ts, ok := row[0].(time.Time)
+		if ok && !end.After(ts) {
+			return false, nil
+		}
+	}
+	for j := range row {
+		if j == 0 {
+			continue // time column
+		}
+		seriesColumnIndex := j - 1
+		if seriesColumnIndex >= len(request.Columns) {
+			break
+		}
+		if request.Columns[seriesColumnIndex].TargetCharacteristicId != nil &&
+			sourceCharacteristicIds[seriesColumnIndex] != nil &&
+			*sourceCharacteristicIds[seriesColumnIndex] != *request.Columns[seriesColumnIndex].TargetCharacteristicId {
+
+			row[j], err = conv.CastWithExtension(row[j], *sourceCharacteristicIds[seriesColumnIndex], *request.Columns[seriesColumnIndex].TargetCharacteristicId, extensions[seriesColumnIndex])
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	if len(timeFormat) > 0 {
+		formatTime2D([][]interface{}{row}, timeFormat)
+	}
+	return true, nil
+}
@@ -0,0 +1,198 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+)
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	one := "1"
+	two := "2"
+	t.Run("Test Response Elements", func(t *testing.T) {
+		t.Parallel()
+		elemA := model.QueriesRequestElement{DeviceId: &one, ServiceId: &one, Columns: []model.QueriesRequestElementColumn{{Name: "a"}}}
+		elemB := model.QueriesRequestElement{DeviceId: &two, ServiceId: &two, Columns: []model.QueriesRequestElementColumn{{Name: "b"}}}
+		element := queriesV2StreamElement{
+			requestIndex: 3,
+			queries:      make([]timescale.Query, 4),
+			columnMatch: map[int]queriesV2ColumnMatch{
+				0: {selIdx: 0, colIdx: 0, elem: &elemA},
+				1: {selIdx: 1, colIdx: 1, elem: &elemB},
+				2: {selIdx: 0, colIdx: 1, elem: &elemA},
+				3: {selIdx: 0, colIdx: 1, elem: &elemA},
+			},
+		}
+		actual := element.responseElements()
+		if len(actual) != 2 {
+			t.Fatal("Unexpected number of response elements", len(actual))
+		}
+		if actual[0].RequestIndex != 3 || *actual[0].DeviceId != one || !reflect.DeepEqual(actual[0].queryIndices, []int{0, 3}) {
+			t.Error("unexpected first element", actual[0])
+		}
+		if *actual[1].DeviceId != two || !reflect.DeepEqual(actual[1].queryIndices, []int{-1, 1}) {
+			t.Error("unexpected second element", actual[1])
+		}
+	})
+
+	t.Run("Test Format Row", func(t *testing.T) {
+		t.Parallel()
+		t1, _ := time.Parse(time.RFC3339, "2022-12-06T06:00:00Z")
+		t2, _ := time.Parse(time.RFC3339, "2022-12-06T07:00:00Z")
+		request := model.QueriesRequestElement{Columns: []model.QueriesRequestElementColumn{{Name: one}}}
+
+		row := []interface{}{t1, 1}
+		keep, err := formatRow(request, row, &t2, nil, nil, nil, time.DateOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !keep || !reflect.DeepEqual(row, []interface{}{"2022-12-06", 1}) {
+			t.Error("unexpected result", keep, row)
+		}
+
+		keep, err = formatRow(request, []interface{}{t2, 1}, &t2, nil, nil, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keep {
+			t.Error("expected row at end to be dropped")
+		}
+
+		keep, err = formatRow(request, []interface{}{t1, nil}, nil, nil, nil, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !keep {
+			t.Error("expected empty row to be kept, trailing empty rows are left out by writeSeries")
+		}
+	})
+
+	t.Run("Test Flush", func(t *testing.T) {
+		t.Parallel()
+		request := model.QueriesRequestElement{Columns: []model.QueriesRequestElementColumn{{Name: one}}}
+		base, _ := time.Parse(time.RFC3339, "2022-12-06T06:00:00Z")
+		rows := 2*streamFlushRows + 1
+		read := func(handle func(row []interface{}) error) error {
+			for i := 0; i < rows; i++ {
+				err := handle([]interface{}{base.Add(time.Duration(i) * time.Second), i})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		// every flush grants another write timeout, a client that stops reading fails the flush and ends the query
+		flushes := 0
+		err := writeSeries(&bytes.Buffer{}, func() error {
+			flushes++
+			return nil
+		}, request, read, nil, nil, nil, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if flushes != 2 {
+			t.Error("unexpected number of flushes", flushes)
+		}
+		stalled := errors.New("client stopped reading")
+		err = writeSeries(&bytes.Buffer{}, func() error { return stalled }, request, read, nil, nil, nil, nil, "")
+		if !errors.Is(err, stalled) {
+			t.Error("expected the failed flush to end the query", err)
+		}
+	})
+
+	t.Run("Test Streamed Like Buffered", func(t *testing.T) {
+		t.Parallel()
+		base, _ := time.Parse(time.RFC3339, "2022-12-06T06:00:00Z")
+		end := base.Add(4 * time.Hour).Format(time.RFC3339)
+		at := func(hours int) time.Time {
+			return base.Add(time.Duration(hours) * time.Hour)
+		}
+		two := 2
+		desc := model.Desc
+		one := 1
+		for _, tc := range []struct {
+			name    string
+			request model.QueriesRequestElement
+		}{
+			{name: "default order", request: model.QueriesRequestElement{Time: &model.QueriesRequestElementTime{End: &end}}},
+			{name: "descending by value with limit", request: model.QueriesRequestElement{OrderColumnIndex: &one, OrderDirection: &desc, Limit: &two}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				request := tc.request
+				request.Columns = []model.QueriesRequestElementColumn{{Name: "a"}}
+				// rows in the order of the database: unsorted, an empty row in between, a row at the end of the time range and a trailing empty row
+				rows := func() [][]interface{} {
+					return [][]interface{}{{at(3), 3.0}, {at(1), 1.0}, {at(4), 4.0}, {at(2), nil}, {at(0), 0.0}, {at(5), nil}}
+				}
+				orderColumnIndex, orderDirection := responseOrder(request)
+				buffered, err := formatResponse(context.Background(), nil, model.PerQuery, []model.QueriesRequestElement{request},
+					[][][]interface{}{rows()}, orderColumnIndex, orderDirection, time.RFC3339, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// read behaves like the database executing timescale.OrderedQuery
+				read := func(handle func(row []interface{}) error) error {
+					sorted := rows()
+					err := model.Sort2D(sorted, orderColumnIndex, orderDirection)
+					if err != nil {
+						return err
+					}
+					if request.Limit != nil {
+						sorted = sorted[:*request.Limit]
+					}
+					for _, row := range sorted {
+						err = handle(row)
+						if err != nil {
+							return err
+						}
+					}
+					return nil
+				}
+				var endTime *time.Time
+				if request.Time != nil {
+					ts, _ := time.Parse(time.RFC3339, *request.Time.End)
+					endTime = &ts
+				}
+				streamed := bytes.Buffer{}
+				streamed.WriteString("[[")
+				err = writeSeries(&streamed, func() error { return nil }, request, read, endTime, nil, nil, nil, time.RFC3339)
+				if err != nil {
+					t.Fatal(err)
+				}
+				streamed.WriteString("]]")
+				expected, err := json.Marshal(buffered)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if streamed.String() != string(expected) {
+					t.Error("streamed response differs from buffered response", streamed.String(), string(expected))
+				}
+			})
+		}
+	})
+}
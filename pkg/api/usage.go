@@ -46,6 +46,8 @@ func init() {
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      429
+// @Failure      503
 // @Failure      500
 // @Router       /usage/devices [GET]
 func UsageDevices() {} // for doc
@@ -61,6 +63,8 @@ func UsageDevices() {} // for doc
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      429
+// @Failure      503
 // @Failure      500
 // @Router       /usage/exports [GET]
 func UsageExports() {} // for doc
@@ -86,7 +90,7 @@ func UsageEndpoint(router gin.IRouter, _ configuration.Config, wrapper *timescal
 			c.Error(errors.Join(err, model.ErrBadRequest))
 			return
 		}
-		ok, _, err := verifier.VerifyAccess(elems, getToken(request), userId)
+		ok, _, err := verifier.VerifyAccess(request.Context(), elems, getToken(request), userId)
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrInternalServerError))
 			return
@@ -95,7 +99,7 @@ func UsageEndpoint(router gin.IRouter, _ configuration.Config, wrapper *timescal
 			c.Error(errors.Join(errors.New("not found"), model.ErrNotFound))
 			return
 		}
-		response, err := wrapper.GetDeviceUsage(deviceIds)
+		response, err := wrapper.GetDeviceUsage(request.Context(), deviceIds)
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrInternalServerError))
 			return
@@ -127,7 +131,7 @@ func UsageEndpoint(router gin.IRouter, _ configuration.Config, wrapper *timescal
 			c.Error(errors.Join(err, model.ErrBadRequest))
 			return
 		}
-		ok, _, err := verifier.VerifyAccess(elems, getToken(request), userId)
+		ok, _, err := verifier.VerifyAccess(request.Context(), elems, getToken(request), userId)
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrInternalServerError))
 			return
@@ -136,7 +140,7 @@ func UsageEndpoint(router gin.IRouter, _ configuration.Config, wrapper *timescal
 			c.Error(errors.Join(errors.New("not found"), model.ErrNotFound))
 			return
 		}
-		response, err := wrapper.GetExportUsage(exportIds)
+		response, err := wrapper.GetExportUsage(request.Context(), exportIds)
 		if err != nil {
 			c.Error(errors.Join(err, model.ErrInternalServerError))
 			return
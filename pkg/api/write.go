@@ -0,0 +1,168 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/SENERGY-Platform/converter/lib/converter"
+	deviceSelection "github.com/SENERGY-Platform/device-selection/pkg/client"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, WriteEndpoint)
+}
+
+// Query godoc
+// @Summary      Insert rows
+// @Description  Inserts rows into the table of a device service or an export. Each row starts with the time as RFC3339 string, followed by one value per column. Requires write permission.
+// @Accept       json
+// @Produce      json
+// @Security Bearer
+// @Param        payload body model.WriteRequest true "rows"
+// @Success      200 {object} model.WriteResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      429
+// @Failure      503
+// @Failure      500
+// @Router       /rows [POST]
+func InsertRows() {} // for doc
+
+// Query godoc
+// @Summary      Upsert rows
+// @Description  Works like inserting rows, but existing rows with the same time are replaced. Requires write permission.
+// @Accept       json
+// @Produce      json
+// @Security Bearer
+// @Param        payload body model.WriteRequest true "rows"
+// @Success      200 {object} model.WriteResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      429
+// @Failure      503
+// @Failure      500
+// @Router       /rows [PUT]
+func UpsertRows() {} // for doc
+
+// Query godoc
+// @Summary      Delete rows
+// @Description  Deletes all rows of a device service or an export with start <= time < end. Requires write permission.
+// @Accept       json
+// @Produce      json
+// @Security Bearer
+// @Param        payload body model.DeleteRowsRequest true "time range"
+// @Success      200 {object} model.WriteResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      429
+// @Failure      503
+// @Failure      500
+// @Router       /rows [DELETE]
+func DeleteRows() {} // for doc
+
+func WriteEndpoint(router gin.IRouter, _ configuration.Config, wrapper *timescale.Wrapper, verifier *verification.Verifier, _ *cache.RemoteCache, _ *converter.Converter, _ deviceSelection.Client) {
+	write := func(c *gin.Context, replace bool) {
+		request := c.Request
+		var writeRequest model.WriteRequest
+		decoder := json.NewDecoder(request.Body)
+		decoder.UseNumber() // integers must not pass through float64
+		err := decoder.Decode(&writeRequest)
+		if err != nil {
+			c.Error(errors.Join(err, model.ErrBadRequest))
+			return
+		}
+		if !writeRequest.Valid() {
+			c.Error(errors.Join(errors.New("Invalid request body"), model.ErrBadRequest))
+			return
+		}
+		ownerUserId, err := writeVerify(c, verifier, writeRequest.ExportId, writeRequest.DeviceId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		rowsAffected, err := wrapper.WriteRows(request.Context(), writeRequest, ownerUserId, replace)
+		if err != nil {
+			c.Error(errors.Join(err, model.GetError(timescale.GetHTTPErrorCode(err))))
+			return
+		}
+		c.JSON(http.StatusOK, model.WriteResponse{RowsAffected: rowsAffected})
+	}
+
+	router.POST("/rows", func(c *gin.Context) {
+		write(c, false)
+	})
+
+	router.PUT("/rows", func(c *gin.Context) {
+		write(c, true)
+	})
+
+	router.DELETE("/rows", func(c *gin.Context) {
+		request := c.Request
+		var deleteRequest model.DeleteRowsRequest
+		err := json.NewDecoder(request.Body).Decode(&deleteRequest)
+		if err != nil {
+			c.Error(errors.Join(err, model.ErrBadRequest))
+			return
+		}
+		if !deleteRequest.Valid() {
+			c.Error(errors.Join(errors.New("Invalid request body"), model.ErrBadRequest))
+			return
+		}
+		ownerUserId, err := writeVerify(c, verifier, deleteRequest.ExportId, deleteRequest.DeviceId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		rowsAffected, err := wrapper.DeleteRows(request.Context(), deleteRequest, ownerUserId)
+		if err != nil {
+			c.Error(errors.Join(err, model.GetError(timescale.GetHTTPErrorCode(err))))
+			return
+		}
+		c.JSON(http.StatusOK, model.WriteResponse{RowsAffected: rowsAffected})
+	})
+}
+
+// writeVerify checks write permission and returns the owner of an export
+func writeVerify(c *gin.Context, verifier *verification.Verifier, exportId *string, deviceId *string) (ownerUserId string, err error) {
+	userId, err := getUserId(c.Request)
+	if err != nil {
+		return "", errors.Join(err, model.ErrBadRequest)
+	}
+	result, err := verifier.VerifyWriteAccess(c.Request.Context(), exportId, deviceId, getToken(c.Request), userId)
+	if err != nil {
+		return "", errors.Join(err, model.ErrInternalServerError)
+	}
+	if !result.Ok {
+		return "", errors.Join(errors.New("forbidden"), model.ErrForbidden)
+	}
+	return result.OwnerUserId, nil
+}
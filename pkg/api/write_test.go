@@ -0,0 +1,42 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+)
+
+func TestWriteValidation(t *testing.T) {
+	server := newTestServer(t, &configuration.ConfigStruct{})
+	for _, tc := range []struct {
+		method   string
+		body     string
+		expected int
+	}{
+		{method: http.MethodPost, body: `{"deviceId": "urn:infai:ses:device:denied", "serviceId": "` + testServiceId + `", "columns": ["energy.total"], "rows": [["2024-01-01T00:00:00Z", 1]]}`, expected: http.StatusForbidden},
+		{method: http.MethodPut, body: `{"deviceId": "` + testDeviceId + `", "serviceId": "` + testServiceId + `", "columns": ["energy.total"], "rows": [["yesterday", 1]]}`, expected: http.StatusBadRequest},
+		{method: http.MethodDelete, body: `{"deviceId": "urn:infai:ses:device:denied", "serviceId": "` + testServiceId + `", "start": "2024-01-01T00:00:00Z", "end": "2024-01-02T00:00:00Z"}`, expected: http.StatusForbidden},
+	} {
+		resp, _ := server.request(t, tc.method, server.url+"/rows", tc.body)
+		if resp.StatusCode != tc.expected {
+			t.Error("unexpected status", tc.method, tc.body, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+)
+
+const (
+	BackendMemcached = "memcached"
+	BackendRedis     = "redis"
+	BackendMemory    = "memory"
+)
+
+var ErrNotStored = errors.New("key already exists in cache")
+
+// Backend stores the entries of RemoteCache. Keys are shared with other platform components,
+// like the last messages stored as device_<id>_service_<id>, so they are used unchanged by all implementations.
+type Backend interface {
+	// Get returns ErrNotFound if key is missing
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key. An expiration of 0 never expires.
+	Set(ctx context.Context, key string, value []byte, expiration time.Duration) error
+	// Add works like Set, but returns ErrNotStored if key exists
+	Add(ctx context.Context, key string, value []byte, expiration time.Duration) error
+	// Delete does not fail for missing keys
+	Delete(ctx context.Context, key string) error
+	Ping(ctx context.Context) error
+}
+
+// NewBackend creates the backend selected by config.CacheBackend, memcached if unset
+func NewBackend(config configuration.Config) (Backend, error) {
+	switch config.CacheBackend {
+	case "", BackendMemcached:
+		return NewMemcachedBackend(config.MemcachedUrls), nil
+	case BackendRedis:
+		return NewRedisBackend(config.RedisUrl)
+	case BackendMemory:
+		return NewMemoryBackend(int(config.CacheMemorySize)), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", config.CacheBackend)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestBackends(t *testing.T) {
+	log.InitForTest()
+	t.Run("memory", func(t *testing.T) {
+		testBackend(t, NewMemoryBackend(0))
+	})
+	t.Run("memcached", func(t *testing.T) {
+		testBackend(t, NewMemcachedBackend([]string{newFakeMemcached(t).addr}))
+	})
+	t.Run("redis", func(t *testing.T) {
+		backend, err := NewRedisBackend("redis://" + miniredis.RunT(t).Addr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		testBackend(t, backend)
+	})
+}
+
+func testBackend(t *testing.T, backend Backend) {
+	ctx := context.Background()
+	key := "device_d_service_s"
+	_, err := backend.Get(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal("expected not found", err)
+	}
+	err = backend.Set(ctx, key, []byte("value"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := backend.Get(ctx, key)
+	if err != nil || string(value) != "value" {
+		t.Fatal("unexpected value", string(value), err)
+	}
+	err = backend.Add(ctx, key, []byte("other"), time.Minute)
+	if !errors.Is(err, ErrNotStored) {
+		t.Fatal("expected not stored", err)
+	}
+	err = backend.Delete(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = backend.Delete(ctx, key)
+	if err != nil {
+		t.Fatal("delete of missing key failed", err)
+	}
+	err = backend.Add(ctx, key, []byte("other"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err = backend.Get(ctx, key)
+	if err != nil || string(value) != "other" {
+		t.Fatal("unexpected value", string(value), err)
+	}
+	err = backend.Ping(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = backend.Get(canceled, key)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("expected canceled", err)
+	}
+}
+
+func TestMemcachedExpiration(t *testing.T) {
+	if memcachedExpiration(time.Minute) != 60 || memcachedExpiration(time.Millisecond) != 1 || memcachedExpiration(0) != 0 {
+		t.Error("expected relative seconds")
+	}
+	// memcached reads more than 30 days as unix time
+	expected := time.Now().Add(60 * 24 * time.Hour).Unix()
+	actual := int64(memcachedExpiration(60 * 24 * time.Hour))
+	if actual < expected-1 || actual > expected+1 {
+		t.Error("expected absolute unix time", expected, actual)
+	}
+}
+
+// fakeMemcached implements the subset of the memcached text protocol used by gomemcache
+type fakeMemcached struct {
+	addr  string
+	mux   sync.Mutex
+	items map[string][]byte
+}
+
+func newFakeMemcached(t *testing.T) *fakeMemcached {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	mc := &fakeMemcached{addr: listener.Addr().String(), items: map[string][]byte{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go mc.serve(conn)
+		}
+	}()
+	return mc
+}
+
+func (mc *fakeMemcached) serve(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		mc.mux.Lock()
+		switch fields[0] {
+		case "get", "gets":
+			for _, key := range fields[1:] {
+				if value, ok := mc.items[key]; ok {
+					_, _ = fmt.Fprintf(rw, "VALUE %s 0 %d 0\r\n%s\r\n", key, len(value), value)
+				}
+			}
+			_, _ = rw.WriteString("END\r\n")
+		case "set", "add":
+			var size int
+			_, _ = fmt.Sscanf(fields[4], "%d", &size)
+			value := make([]byte, size+2)
+			_, err = io.ReadFull(rw, value)
+			if _, exists := mc.items[fields[1]]; err == nil && fields[0] == "add" && exists {
+				_, _ = rw.WriteString("NOT_STORED\r\n")
+			} else if err == nil {
+				mc.items[fields[1]] = value[:size]
+				_, _ = rw.WriteString("STORED\r\n")
+			}
+		case "version":
+			_, _ = rw.WriteString("VERSION 1.6.0\r\n")
+		case "delete":
+			if _, ok := mc.items[fields[1]]; ok {
+				delete(mc.items, fields[1])
+				_, _ = rw.WriteString("DELETED\r\n")
+			} else {
+				_, _ = rw.WriteString("NOT_FOUND\r\n")
+			}
+		default:
+			_, _ = rw.WriteString("ERROR\r\n")
+		}
+		mc.mux.Unlock()
+		if err != nil || rw.Flush() != nil {
+			return
+		}
+	}
+}
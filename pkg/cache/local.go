@@ -19,9 +19,12 @@ package cache
 import (
 	"encoding/json"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
 	"github.com/coocood/freecache"
 )
 
@@ -39,6 +42,15 @@ func NewLocal() *LocalCache {
 	return &LocalCache{l1: freecache.NewCache(LocalCacheSize), expiration: LocalCacheExpirationInSec}
 }
 
+// NewLocalWithExpiration works like NewLocal, but entries expire after expiration instead of LocalCacheExpirationInSec
+func NewLocalWithExpiration(expiration time.Duration) *LocalCache {
+	return newLocal(LocalCacheSize, expiration)
+}
+
+func newLocal(size int, expiration time.Duration) *LocalCache {
+	return &LocalCache{l1: freecache.NewCache(size), expiration: int(expiration.Seconds())}
+}
+
 func (this *LocalCache) Get(key string) (value []byte, err error) {
 	value, err = this.l1.Get([]byte(key))
 	if err == freecache.ErrNotFound {
@@ -57,6 +69,7 @@ func (this *LocalCache) Set(key string, value []byte) {
 
 func (this *LocalCache) Use(key string, getter func() (interface{}, error), result interface{}) (err error) {
 	value, err := this.Get(key)
+	metrics.ObserveCache("local", err == nil)
 	if err == nil {
 		err = json.Unmarshal(value, result)
 		return
@@ -74,3 +87,25 @@ func (this *LocalCache) Use(key string, getter func() (interface{}, error), resu
 	this.Set(key, value)
 	return json.Unmarshal(value, &result)
 }
+
+func (this *LocalCache) Delete(key string) {
+	this.l1.Del([]byte(key))
+}
+
+// DeleteSuffix removes all entries with keys ending in suffix and returns their number.
+// Iterates the whole cache, meant for rare events like changed permissions.
+func (this *LocalCache) DeleteSuffix(suffix string) (deleted int) {
+	keys := [][]byte{}
+	iterator := this.l1.NewIterator()
+	for entry := iterator.Next(); entry != nil; entry = iterator.Next() {
+		if strings.HasSuffix(string(entry.Key), suffix) {
+			keys = append(keys, entry.Key)
+		}
+	}
+	for _, key := range keys {
+		if this.l1.Del(key) {
+			deleted++
+		}
+	}
+	return deleted
+}
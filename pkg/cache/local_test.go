@@ -0,0 +1,40 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cache
+
+import "testing"
+
+func TestLocalDeleteSuffix(t *testing.T) {
+	c := NewLocal()
+	c.Set("user1urn:infai:ses:device:1", []byte("true"))
+	c.Set("user2urn:infai:ses:device:1", []byte("true"))
+	c.Set("w:user1urn:infai:ses:device:1", []byte("true"))
+	c.Set("user1urn:infai:ses:device:2", []byte("true"))
+
+	deleted := c.DeleteSuffix("urn:infai:ses:device:1")
+	if deleted != 3 {
+		t.Error("unexpected number of deleted entries", deleted)
+	}
+	_, err := c.Get("user1urn:infai:ses:device:1")
+	if err != ErrNotFound {
+		t.Error("expected entry to be deleted", err)
+	}
+	_, err = c.Get("user1urn:infai:ses:device:2")
+	if err != nil {
+		t.Error("expected other entry to be kept", err)
+	}
+}
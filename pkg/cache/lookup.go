@@ -0,0 +1,79 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
+)
+
+// lookup returns the entity cached as cache_id from the in-process l1 cache, the backend or get, in this order.
+// Entities from get are stored in both caches. Concurrent lookups of the same entity with the same token share one call,
+// so goroutines of a request don't repeat each other's work. Only the token of the first caller is used by get.
+// The shared call is not canceled with ctx, other callers may still wait for it.
+func lookup[T any](ctx context.Context, rc *RemoteCache, cache string, id string, token string, get func() (T, error)) (result T, err error) {
+	key := cache + "_" + id
+	if rc.l1 != nil {
+		value, err := rc.l1.Get(key)
+		metrics.ObserveCache("l1_"+cache, err == nil)
+		if err == nil {
+			err = json.Unmarshal(value, &result)
+			return result, err
+		}
+	}
+	err = ctx.Err()
+	if err != nil {
+		return result, err
+	}
+	flight := rc.flights.DoChan(key+"\x00"+token, func() (interface{}, error) {
+		flightCtx := context.WithoutCancel(ctx)
+		value, err := rc.get(flightCtx, cache, key)
+		if err == nil {
+			rc.l1Set(key, value)
+			return value, nil
+		}
+		entity, err := get()
+		if err != nil {
+			return nil, err
+		}
+		value, err = json.Marshal(entity)
+		if err != nil {
+			return nil, err
+		}
+		rc.set(flightCtx, key, value, rc.expiration(cache))
+		rc.l1Set(key, value)
+		return value, nil
+	})
+	select {
+	case <-ctx.Done():
+		return result, ctx.Err()
+	case response := <-flight:
+		if response.Err != nil {
+			return result, response.Err
+		}
+		err = json.Unmarshal(response.Val.([]byte), &result)
+		return result, err
+	}
+}
+
+func (rc *RemoteCache) l1Set(key string, value []byte) {
+	if rc.l1 != nil {
+		rc.l1.Set(key, value)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-repository/lib/api"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+)
+
+type countingDeviceRepo struct {
+	api.Controller
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (repo *countingDeviceRepo) GetService(id string) (models.Service, error, int) {
+	repo.calls.Add(1)
+	<-repo.release
+	return models.Service{Id: id, Name: "service"}, nil, 200
+}
+
+func TestLookup(t *testing.T) {
+	log.InitForTest()
+	repo := &countingDeviceRepo{release: make(chan struct{})}
+	rc := NewRemoteWithBackend(&configuration.ConfigStruct{CacheL1Size: 1024 * 1024, CacheL1Expiration: "1m"}, NewMemoryBackend(0), repo, nil)
+	ctx := context.Background()
+
+	t.Run("concurrent lookups share one call", func(t *testing.T) {
+		wg := sync.WaitGroup{}
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				service, err := rc.GetService(ctx, "s1")
+				if err != nil || service.Name != "service" {
+					t.Error("unexpected result", service, err)
+				}
+			}()
+		}
+		time.Sleep(100 * time.Millisecond)
+		close(repo.release)
+		wg.Wait()
+		if repo.calls.Load() != 1 {
+			t.Error("unexpected number of upstream calls", repo.calls.Load())
+		}
+	})
+
+	t.Run("l1 hit", func(t *testing.T) {
+		_, err := rc.GetService(ctx, "s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repo.calls.Load() != 1 {
+			t.Error("unexpected number of upstream calls", repo.calls.Load())
+		}
+	})
+
+	t.Run("invalidated", func(t *testing.T) {
+		err := rc.Invalidate(ctx, "service", "s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = rc.GetService(ctx, "s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repo.calls.Load() != 2 {
+			t.Error("unexpected number of upstream calls", repo.calls.Load())
+		}
+	})
+
+	t.Run("canceled caller", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := rc.GetService(canceled, "s2")
+		if err != context.Canceled {
+			t.Error("expected canceled", err)
+		}
+	})
+}
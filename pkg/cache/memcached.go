@@ -0,0 +1,122 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// memcachedMaxRelativeExpiration is the longest expiration memcached reads as relative, longer ones are read as unix time
+const memcachedMaxRelativeExpiration = 30 * 24 * time.Hour
+
+type memcachedBackend struct {
+	urls []string
+	mc   *memcache.Client
+}
+
+func NewMemcachedBackend(urls []string) Backend {
+	backend := &memcachedBackend{urls: urls}
+	backend.init()
+	return backend
+}
+
+func (backend *memcachedBackend) init() {
+	log.Logger.Info("(Re-)init memcached Client")
+	backend.mc = memcache.New(backend.urls...)
+}
+
+func (backend *memcachedBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	item, err := backend.mc.Get(key)
+	if err != nil && err != memcache.ErrCacheMiss && err != memcache.ErrCASConflict && err != memcache.ErrNotStored && err != memcache.ErrServerError && err != memcache.ErrNoStats && err != memcache.ErrMalformedKey {
+		backend.init()
+		item, err = backend.mc.Get(key)
+		if err != nil {
+			log.Logger.Warn("mc get failed", attributes.ErrorKey, err)
+		}
+	}
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.Value, nil
+}
+
+func (backend *memcachedBackend) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	item := &memcache.Item{Key: key, Value: value, Expiration: memcachedExpiration(expiration)}
+	err = backend.mc.Set(item)
+	if err != nil {
+		backend.init()
+		err = backend.mc.Set(item)
+	}
+	return err
+}
+
+func (backend *memcachedBackend) Add(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	err = backend.mc.Add(&memcache.Item{Key: key, Value: value, Expiration: memcachedExpiration(expiration)})
+	if errors.Is(err, memcache.ErrNotStored) {
+		return ErrNotStored
+	}
+	return err
+}
+
+func (backend *memcachedBackend) Delete(ctx context.Context, key string) error {
+	_, err := model.CallWithContext(ctx, func() (struct{}, error) {
+		err := backend.mc.Delete(key)
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return struct{}{}, nil
+		}
+		return struct{}{}, err
+	})
+	return err
+}
+
+// Ping checks that all memcached servers are reachable
+func (backend *memcachedBackend) Ping(ctx context.Context) error {
+	_, err := model.CallWithContext(ctx, func() (struct{}, error) {
+		return struct{}{}, backend.mc.Ping()
+	})
+	return err
+}
+
+// memcachedExpiration converts expiration to seconds, or to the absolute unix time if it is too long to be relative
+func memcachedExpiration(expiration time.Duration) int32 {
+	if expiration > memcachedMaxRelativeExpiration {
+		return int32(time.Now().Add(expiration).Unix())
+	}
+	return int32(expirationSeconds(expiration))
+}
@@ -0,0 +1,95 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coocood/freecache"
+)
+
+// memoryBackend keeps entries in process. Meant for single instances and tests, entries are not shared with other services.
+type memoryBackend struct {
+	cache *freecache.Cache
+	mux   sync.Mutex // makes Add atomic
+}
+
+// NewMemoryBackend creates an in-process backend of size bytes, LocalCacheSize if size is 0
+func NewMemoryBackend(size int) Backend {
+	if size <= 0 {
+		size = LocalCacheSize
+	}
+	return &memoryBackend{cache: freecache.NewCache(size)}
+}
+
+func (backend *memoryBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	value, err := backend.cache.Get([]byte(key))
+	if err == freecache.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (backend *memoryBackend) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	backend.mux.Lock()
+	defer backend.mux.Unlock()
+	return backend.cache.Set([]byte(key), value, expirationSeconds(expiration))
+}
+
+func (backend *memoryBackend) Add(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	backend.mux.Lock()
+	defer backend.mux.Unlock()
+	_, err = backend.cache.Get([]byte(key))
+	if err == nil {
+		return ErrNotStored
+	}
+	return backend.cache.Set([]byte(key), value, expirationSeconds(expiration))
+}
+
+func (backend *memoryBackend) Delete(ctx context.Context, key string) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	backend.mux.Lock()
+	defer backend.mux.Unlock()
+	backend.cache.Del([]byte(key))
+	return nil
+}
+
+func (backend *memoryBackend) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// expirationSeconds rounds up, freecache would never expire entries shorter than a second
+func expirationSeconds(expiration time.Duration) int {
+	return int((expiration + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,69 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type redisBackend struct {
+	client *redis.Client
+}
+
+// NewRedisBackend connects to Redis or Valkey, url has the form redis://<user>:<password>@<host>:<port>/<db>
+func NewRedisBackend(url string) (Backend, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return &redisBackend{client: redis.NewClient(options)}, nil
+}
+
+func (backend *redisBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := backend.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (backend *redisBackend) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return backend.client.Set(ctx, key, value, expiration).Err()
+}
+
+func (backend *redisBackend) Add(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	stored, err := backend.client.SetNX(ctx, key, value, expiration).Result()
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return ErrNotStored
+	}
+	return nil
+}
+
+func (backend *redisBackend) Delete(ctx context.Context, key string) error {
+	return backend.client.Del(ctx, key).Err()
+}
+
+func (backend *redisBackend) Ping(ctx context.Context) error {
+	return backend.client.Ping(ctx).Err()
+}
@@ -17,6 +17,7 @@
 package cache
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -33,261 +34,323 @@ import (
 	dsmodel "github.com/SENERGY-Platform/device-selection/pkg/model"
 	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	"github.com/SENERGY-Platform/models/go/models"
+	util "github.com/SENERGY-Platform/timescale-tableworker/pkg/lib/handler"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
-	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 )
 
 type RemoteCache struct {
-	mc              *memcache.Client
+	backend         Backend
 	config          configuration.Config
 	deviceRepo      api.Controller
 	deviceSelection deviceSelection.Client
+	expirations     map[string]time.Duration // cache name to expiration
+	l1              *LocalCache              // in front of the backend for entities, nil if disabled
+	flights         singleflight.Group
 }
 
+const defaultRemoteExpiration = 5 * time.Minute
+
+const defaultL1Expiration = time.Minute
+
+// defaultWriteBackExpiration is short, entries written back from the database are not updated on new messages
+const defaultWriteBackExpiration = time.Minute
+
+const selectablesGenerationKey = "selectables_generation"
+
 var NotCachableError = errors.New("not cachable")
 
 type Entry struct {
 	Time  time.Time              `json:"time"`
 	Value map[string]interface{} `json:"value"`
+	// WrittenBack marks entries this service read from the database, components receiving device messages overwrite them
+	WrittenBack bool `json:"written_back,omitempty"`
 }
 
-func NewRemote(config configuration.Config, deviceRepo api.Controller, deviceSelection deviceSelection.Client) *RemoteCache {
-	rc := &RemoteCache{config: config, deviceRepo: deviceRepo, deviceSelection: deviceSelection}
-	rc.initMemcached()
+// Row returns the time and the values of columns with their math applied, like a database row.
+// Values of exports are keyed by column name, values of devices are nested by path.
+func (entry Entry) Row(columns []model.QueriesRequestElementColumn) ([]interface{}, error) {
+	row := make([]interface{}, len(columns)+1)
+	row[0] = entry.Time
+	for i, column := range columns {
+		value, ok := entry.Value[column.Name]
+		if !ok {
+			value, ok = entry.Value[strings.Trim(util.HashFieldNameIfNeeded(column.Name), "\"")] // long export columns as read from the database
+		}
+		if !ok {
+			value = getDeepEntry(entry.Value, column.Name)
+		}
+		if column.Math != nil {
+			var err error
+			value, err = applyMath(value, *column.Math)
+			if err != nil {
+				return nil, err
+			}
+		}
+		row[i+1] = value
+	}
+	return row, nil
+}
+
+// NewRemote creates a RemoteCache with the backend selected by config.CacheBackend
+func NewRemote(config configuration.Config, deviceRepo api.Controller, deviceSelection deviceSelection.Client) (*RemoteCache, error) {
+	backend, err := NewBackend(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteWithBackend(config, backend, deviceRepo, deviceSelection), nil
+}
+
+func NewRemoteWithBackend(config configuration.Config, backend Backend, deviceRepo api.Controller, deviceSelection deviceSelection.Client) *RemoteCache {
+	rc := &RemoteCache{config: config, backend: backend, deviceRepo: deviceRepo, deviceSelection: deviceSelection, expirations: map[string]time.Duration{}}
+	for name, value := range config.CacheExpirations {
+		expiration, err := time.ParseDuration(value)
+		if err != nil {
+			log.Logger.Warn("invalid cache expiration, using default", "cache", name, attributes.ErrorKey, err)
+			continue
+		}
+		rc.expirations[name] = expiration
+	}
+	if config.CacheL1Size > 0 {
+		l1Expiration, err := time.ParseDuration(config.CacheL1Expiration)
+		if err != nil {
+			log.Logger.Warn("invalid l1 cache expiration, using default", attributes.ErrorKey, err)
+			l1Expiration = defaultL1Expiration
+		}
+		rc.l1 = newLocal(int(config.CacheL1Size), l1Expiration)
+	}
 	return rc
 }
 
-func (lv *RemoteCache) initMemcached() {
-	log.Logger.Info("(Re-)init memcached Client")
-	lv.mc = memcache.New(lv.config.MemcachedUrls...)
+// expiration returns the configured expiration of a cache
+func (rc *RemoteCache) expiration(cache string) time.Duration {
+	expiration, ok := rc.expirations[cache]
+	if !ok {
+		return defaultRemoteExpiration
+	}
+	return expiration
 }
 
-func (lv *RemoteCache) GetLastValuesFromCache(request model.QueriesRequestElement, forceTZ *string) ([][]interface{}, error) {
-	if request.DeviceId == nil || request.ServiceId == nil || request.Limit == nil || *request.Limit != 1 ||
+func (lv *RemoteCache) GetLastValuesFromCache(ctx context.Context, request model.QueriesRequestElement, forceTZ *string) (result [][]interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetLastValuesFromCache")
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", err == nil)) // errors are misses, callers fall back to the database
+		span.End()
+	}()
+	if (request.ExportId == nil && (request.DeviceId == nil || request.ServiceId == nil)) || request.Limit == nil || *request.Limit != 1 ||
 		request.Time != nil || request.GroupTime != nil || request.Filters != nil || request.DeviceGroupId != nil || forceTZ != nil {
 		return nil, NotCachableError
 	}
 
 	for _, col := range request.Columns {
-		if col.Math != nil || col.GroupType != nil {
+		if col.GroupType != nil {
 			return nil, NotCachableError
 		}
 	}
 
-	key := "device_" + *request.DeviceId + "_service_" + *request.ServiceId
-	item, err := lv.mcGet(key)
+	key := lastMessageKey(request)
+	value, err := lv.get(ctx, "last_values", key)
 	if err != nil {
 		return nil, err
 	}
 	var entry Entry
-	err = json.Unmarshal(item.Value, &entry)
+	err = json.Unmarshal(value, &entry)
 	if err != nil {
 		return nil, err
 	}
-	res := make([]interface{}, len(request.Columns)+1)
-	res[0] = entry.Time
-	for i := range request.Columns {
-		res[i+1] = getDeepEntry(entry.Value, request.Columns[i].Name)
+	row, err := entry.Row(request.Columns)
+	if err != nil {
+		return nil, err
 	}
+	return [][]interface{}{row}, nil
+}
 
-	return [][]interface{}{res}, nil
+// lastMessageKey returns the key of the last message of the export or device service of request.
+// Last messages of exports are only cached as export_<id> by WriteBackLastExportMessage after they were read from the database.
+func lastMessageKey(request model.QueriesRequestElement) string {
+	if request.ExportId != nil {
+		return "export_" + *request.ExportId
+	}
+	return "device_" + *request.DeviceId + "_service_" + *request.ServiceId
 }
 
-func (lv *RemoteCache) GetLastMessageFromCache(deviceId string, serviceId string) (entry Entry, err error) {
+func (lv *RemoteCache) GetLastMessageFromCache(ctx context.Context, deviceId string, serviceId string) (entry Entry, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetLastMessageFromCache", attribute.String("device_id", deviceId), attribute.String("service_id", serviceId))
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", err == nil)) // errors are misses, callers fall back to the database
+		span.End()
+	}()
 	key := "device_" + deviceId + "_service_" + serviceId
-	item, err := lv.mcGet(key)
+	value, err := lv.get(ctx, "last_message", key)
 	if err != nil {
 		return entry, err
 	}
-	err = json.Unmarshal(item.Value, &entry)
+	err = json.Unmarshal(value, &entry)
 	if err != nil {
 		return entry, err
 	}
 	return
 }
 
-func (this *RemoteCache) GetService(serviceId string) (service models.Service, err error) {
-	cachedItem, err := this.mcGet("service_" + serviceId)
-	if err == nil {
-		err = json.Unmarshal(cachedItem.Value, &service)
-		if err != nil {
-			return service, err
-		}
-	} else {
-		service, err, _ = this.deviceRepo.GetService(serviceId)
-		if err != nil {
-			return service, err
-		}
-		bytes, err := json.Marshal(service)
-		if err != nil {
-			return service, err
-		}
-		this.mcSet(&memcache.Item{
-			Key:        "service_" + service.Id,
-			Value:      bytes,
-			Expiration: 5 * 60,
-		})
-	}
-	return service, err
+// WriteBackLastMessage stores entry, read from the database, as last message of a device service with the "last_message_write_back" expiration.
+// Existing entries are kept, they were either written back concurrently or are newer messages.
+func (lv *RemoteCache) WriteBackLastMessage(ctx context.Context, deviceId string, serviceId string, entry Entry) error {
+	return lv.writeBack(ctx, "device_"+deviceId+"_service_"+serviceId, entry)
 }
 
-func (this *RemoteCache) GetConcept(conceptId string) (concept models.Concept, err error) {
-	cachedItem, err := this.mcGet("concept_" + conceptId)
-	if err == nil {
-		err = json.Unmarshal(cachedItem.Value, &concept)
-		if err != nil {
-			return
-		}
-	} else {
-		concept, err, _ = this.deviceRepo.GetConceptWithoutCharacteristics(conceptId)
-		if err != nil {
-			return
-		}
-		bytes, err := json.Marshal(concept)
-		if err != nil {
-			return concept, err
-		}
-		this.mcSet(&memcache.Item{
-			Key:        "concept_" + concept.Id,
-			Value:      bytes,
-			Expiration: 5 * 60,
-		})
+// WriteBackLastExportMessage works like WriteBackLastMessage for the last row of an export
+func (lv *RemoteCache) WriteBackLastExportMessage(ctx context.Context, exportId string, entry Entry) error {
+	return lv.writeBack(ctx, "export_"+exportId, entry)
+}
+
+func (lv *RemoteCache) writeBack(ctx context.Context, key string, entry Entry) error {
+	entry.WrittenBack = true
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
 	}
-	return
+	expiration, ok := lv.expirations["last_message_write_back"]
+	if !ok {
+		expiration = defaultWriteBackExpiration
+	}
+	err = lv.backend.Add(ctx, key, value, expiration)
+	if errors.Is(err, ErrNotStored) {
+		return nil
+	}
+	return err
+}
+
+// DeleteLastMessage removes the cached last message of a device service, which also serves last values
+func (lv *RemoteCache) DeleteLastMessage(ctx context.Context, deviceId string, serviceId string) error {
+	return lv.backend.Delete(ctx, "device_"+deviceId+"_service_"+serviceId)
+}
+
+// DeleteLastExportMessage removes the cached last row of an export
+func (lv *RemoteCache) DeleteLastExportMessage(ctx context.Context, exportId string) error {
+	return lv.backend.Delete(ctx, "export_"+exportId)
+}
+
+func (this *RemoteCache) GetService(ctx context.Context, serviceId string) (service models.Service, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetService", attribute.String("service_id", serviceId))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return lookup(ctx, this, "service", serviceId, "", func() (models.Service, error) {
+		start := time.Now()
+		service, err, _ := this.deviceRepo.GetService(serviceId)
+		metrics.ObserveUpstream(metrics.DeviceRepository, start, err)
+		return service, err
+	})
 }
 
-func (this *RemoteCache) StoreSecretQuery(query model.PreparedQueriesRequestElement) (secret string, err error) {
+func (this *RemoteCache) GetConcept(ctx context.Context, conceptId string) (concept models.Concept, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetConcept", attribute.String("concept_id", conceptId))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return lookup(ctx, this, "concept", conceptId, "", func() (models.Concept, error) {
+		start := time.Now()
+		concept, err, _ := this.deviceRepo.GetConceptWithoutCharacteristics(conceptId)
+		metrics.ObserveUpstream(metrics.DeviceRepository, start, err)
+		return concept, err
+	})
+}
+
+func (this *RemoteCache) StoreSecretQuery(ctx context.Context, query model.PreparedQueriesRequestElement) (secret string, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.StoreSecretQuery")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	bytes, err := json.Marshal(query)
 	if err != nil {
 		return "", err
 	}
 	uid := uuid.NewString()
-	err = this.mc.Set(&memcache.Item{ //not using mcSet to ensure error propagates
-		Key:        "secretquery_" + uid,
-		Value:      bytes,
-		Expiration: 30,
-	})
+	err = this.backend.Set(ctx, "secretquery_"+uid, bytes, 30*time.Second) //not using set to ensure error propagates
 	return uid, err
 }
 
-func (this *RemoteCache) GetSecretQuery(secret string) (query model.PreparedQueriesRequestElement, err error) {
+func (this *RemoteCache) GetSecretQuery(ctx context.Context, secret string) (query model.PreparedQueriesRequestElement, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetSecretQuery")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	query = model.PreparedQueriesRequestElement{}
-	item, err := this.mcGet("secretquery_" + secret)
+	value, err := this.get(ctx, "secret_query", "secretquery_"+secret)
 	if err != nil {
 		return query, err
 	}
-	err = this.mc.Delete("secretquery_" + secret)
+	err = this.backend.Delete(ctx, "secretquery_"+secret)
 	if err != nil {
 		return query, err
 	}
-	err = json.Unmarshal(item.Value, &query)
+	err = json.Unmarshal(value, &query)
 	return query, err
 }
 
-func (this *RemoteCache) GetDeviceGroup(deviceGroupId string, token string) (deviceGroup models.DeviceGroup, err error) {
-	cachedItem, err := this.mcGet("device_group_" + deviceGroupId)
-	if err == nil {
-		err = json.Unmarshal(cachedItem.Value, &deviceGroup)
-		if err != nil {
-			return
-		}
-	} else {
-		deviceGroup, err, _ = this.deviceRepo.ReadDeviceGroup(deviceGroupId, token, false)
-		if err != nil {
-			return
-		}
-		bytes, err := json.Marshal(deviceGroup)
-		if err != nil {
-			return deviceGroup, err
-		}
-		this.mcSet(&memcache.Item{
-			Key:        "device_group_" + deviceGroup.Id,
-			Value:      bytes,
-			Expiration: 5 * 60,
-		})
-	}
-	return
+func (this *RemoteCache) GetDeviceGroup(ctx context.Context, deviceGroupId string, token string) (deviceGroup models.DeviceGroup, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetDeviceGroup", attribute.String("device_group_id", deviceGroupId))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return lookup(ctx, this, "device_group", deviceGroupId, token, func() (models.DeviceGroup, error) {
+		start := time.Now()
+		deviceGroup, err, _ := this.deviceRepo.ReadDeviceGroup(deviceGroupId, token, false)
+		metrics.ObserveUpstream(metrics.DeviceRepository, start, err)
+		return deviceGroup, err
+	})
 }
 
-func (this *RemoteCache) GetDevice(deviceId string, token string) (device models.Device, err error) {
-	cachedItem, err := this.mcGet("device_" + deviceId)
-	if err == nil {
-		err = json.Unmarshal(cachedItem.Value, &device)
-		if err != nil {
-			return device, err
-		}
-	} else {
-		device, err, _ = this.deviceRepo.ReadDevice(deviceId, token, drmodel.READ)
-		if err != nil {
-			return device, err
-		}
-		bytes, err := json.Marshal(device)
-		if err != nil {
-			return device, err
-		}
-		this.mcSet(&memcache.Item{
-			Key:        "device_" + device.Id,
-			Value:      bytes,
-			Expiration: 5 * 60,
-		})
-	}
-	return device, err
+func (this *RemoteCache) GetDevice(ctx context.Context, deviceId string, token string) (device models.Device, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetDevice", attribute.String("device_id", deviceId))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return lookup(ctx, this, "device", deviceId, token, func() (models.Device, error) {
+		start := time.Now()
+		device, err, _ := this.deviceRepo.ReadDevice(deviceId, token, drmodel.READ)
+		metrics.ObserveUpstream(metrics.DeviceRepository, start, err)
+		return device, err
+	})
 }
 
-func (this *RemoteCache) GetFunction(functionId string) (function models.Function, err error) {
-	cachedItem, err := this.mcGet("function_" + functionId)
-	if err == nil {
-		err = json.Unmarshal(cachedItem.Value, &function)
-		if err != nil {
-			return
-		}
-	} else {
-		function, err, _ = this.deviceRepo.GetFunction(functionId)
-		if err != nil {
-			return
-		}
-		bytes, err := json.Marshal(function)
-		if err != nil {
-			return function, err
-		}
-		this.mcSet(&memcache.Item{
-			Key:        "function_" + function.Id,
-			Value:      bytes,
-			Expiration: 5 * 60,
-		})
-	}
-	return
+func (this *RemoteCache) GetFunction(ctx context.Context, functionId string) (function models.Function, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetFunction", attribute.String("function_id", functionId))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return lookup(ctx, this, "function", functionId, "", func() (models.Function, error) {
+		start := time.Now()
+		function, err, _ := this.deviceRepo.GetFunction(functionId)
+		metrics.ObserveUpstream(metrics.DeviceRepository, start, err)
+		return function, err
+	})
 }
 
-func (this *RemoteCache) GetLocation(locationId string, token string) (location models.Location, err error) {
-	cachedItem, err := this.mcGet("location_" + locationId)
-	if err == nil {
-		err = json.Unmarshal(cachedItem.Value, &location)
-		if err != nil {
-			return
-		}
-	} else {
-		location, err, _ = this.deviceRepo.GetLocation(locationId, token)
-		if err != nil {
-			return
-		}
-		bytes, err := json.Marshal(location)
-		if err != nil {
-			return location, err
-		}
-		this.mcSet(&memcache.Item{
-			Key:        "location_" + location.Id,
-			Value:      bytes,
-			Expiration: 5 * 60,
-		})
-	}
-	return
+func (this *RemoteCache) GetLocation(ctx context.Context, locationId string, token string) (location models.Location, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetLocation", attribute.String("location_id", locationId))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return lookup(ctx, this, "location", locationId, token, func() (models.Location, error) {
+		start := time.Now()
+		location, err, _ := this.deviceRepo.GetLocation(locationId, token)
+		metrics.ObserveUpstream(metrics.DeviceRepository, start, err)
+		return location, err
+	})
 }
 
-func (this *RemoteCache) GetSelectables(userid string, token string, criteria []models.DeviceGroupFilterCriteria, options *deviceSelection.GetSelectablesOptions) (res []dsmodel.Selectable, code int, err error) {
+func (this *RemoteCache) GetSelectables(ctx context.Context, userid string, token string, criteria []models.DeviceGroupFilterCriteria, options *deviceSelection.GetSelectablesOptions) (res []dsmodel.Selectable, code int, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteCache.GetSelectables")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	hasher := sha256.New()
 	criteriaBytes, err := json.Marshal(criteria)
 	if err != nil {
@@ -311,52 +374,107 @@ func (this *RemoteCache) GetSelectables(userid string, token string, criteria []
 		return
 	}
 
-	key := "selectables_" + hex.EncodeToString(hasher.Sum(nil))
-	cachedItem, err := this.mcGet(key)
+	generation, err := this.selectablesGeneration(ctx)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return
+	}
+	key := "selectables_" + generation + "_" + hex.EncodeToString(hasher.Sum(nil))
+	cached, err := this.get(ctx, "selectables", key)
 	if err == nil {
-		err = json.Unmarshal(cachedItem.Value, &res)
+		err = json.Unmarshal(cached, &res)
 		if err != nil {
 			return
 		}
 	} else {
-		res, code, err = this.deviceSelection.GetSelectables(token, criteria, options)
+		type selectables struct {
+			res  []dsmodel.Selectable
+			code int
+		}
+		var result selectables
+		result, err = model.CallWithContext(ctx, func() (selectables, error) {
+			start := time.Now()
+			res, code, err := this.deviceSelection.GetSelectables(token, criteria, options)
+			metrics.ObserveUpstream(metrics.DeviceSelection, start, err)
+			return selectables{res: res, code: code}, err
+		})
+		res, code = result.res, result.code
 		if err != nil {
+			if ctx.Err() != nil {
+				code = http.StatusGatewayTimeout
+			}
 			return
 		}
 		bytes, err := json.Marshal(res)
 		if err != nil {
 			return res, http.StatusInternalServerError, err
 		}
-		this.mcSet(&memcache.Item{
-			Key:        key,
-			Value:      bytes,
-			Expiration: 5 * 60,
-		})
+		this.set(ctx, key, bytes, this.expiration("selectables"))
 	}
 	return
 }
 
-func (rc *RemoteCache) mcSet(item *memcache.Item) {
-	err := rc.mc.Set(item)
-	if err != nil {
-		rc.initMemcached()
-		err := rc.mc.Set(item)
-		if err != nil {
-			log.Logger.Warn("mc set failed", attributes.ErrorKey, err)
-		}
+// Invalidate removes the entry of id from a cache like device or service, so the next getter call reads it from upstream
+func (rc *RemoteCache) Invalidate(ctx context.Context, cache string, id string) error {
+	if rc.l1 != nil {
+		rc.l1.Delete(cache + "_" + id)
 	}
+	return rc.backend.Delete(ctx, cache+"_"+id)
 }
 
-func (rc *RemoteCache) mcGet(key string) (item *memcache.Item, err error) {
-	item, err = rc.mc.Get(key)
-	if err != nil && err != memcache.ErrCacheMiss && err != memcache.ErrCASConflict && err != memcache.ErrNotStored && err != memcache.ErrServerError && err != memcache.ErrNoStats && err != memcache.ErrMalformedKey {
-		rc.initMemcached()
-		item, err = rc.mc.Get(key)
+// InvalidateSelectables starts a new generation of cached selectables. They are cached by criteria, so single entries
+// can't be found for a changed device. Old entries are no longer read and expire.
+func (rc *RemoteCache) InvalidateSelectables(ctx context.Context) error {
+	return rc.backend.Set(ctx, selectablesGenerationKey, []byte(uuid.NewString()), 0)
+}
+
+// selectablesGeneration returns the current generation of cached selectables, a new one is started if the backend lost it
+func (rc *RemoteCache) selectablesGeneration(ctx context.Context) (string, error) {
+	value, err := rc.get(ctx, "selectables_generation", selectablesGenerationKey)
+	if err == nil {
+		return string(value), nil
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	generation := uuid.NewString()
+	err = rc.backend.Add(ctx, selectablesGenerationKey, []byte(generation), 0)
+	if errors.Is(err, ErrNotStored) { // started concurrently
+		value, err = rc.get(ctx, "selectables_generation", selectablesGenerationKey)
 		if err != nil {
-			log.Logger.Warn("mc get failed", attributes.ErrorKey, err)
+			return "", err
 		}
+		return string(value), nil
 	}
-	return
+	if err != nil {
+		log.Logger.Warn("could not store selectables generation", attributes.ErrorKey, err)
+	}
+	return generation, nil
+}
+
+// Ping checks that the backend is reachable
+func (rc *RemoteCache) Ping(ctx context.Context) error {
+	return rc.backend.Ping(ctx)
+}
+
+// set stores value without failing, entries can be read from upstream again
+func (rc *RemoteCache) set(ctx context.Context, key string, value []byte, expiration time.Duration) {
+	err := rc.backend.Set(ctx, key, value, expiration)
+	if err != nil {
+		log.Logger.Warn("cache set failed", attributes.ErrorKey, err)
+	}
+}
+
+// get returns the error of ctx instead of a value if ctx is already done. Hits and misses are counted per cache name.
+func (rc *RemoteCache) get(ctx context.Context, cache string, key string) (value []byte, err error) {
+	err = ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		metrics.ObserveCache(cache, err == nil)
+	}()
+	return rc.backend.Get(ctx, key)
 }
 
 func getDeepEntry(m map[string]interface{}, path string) interface{} {
@@ -382,3 +500,41 @@ func getDeepEntry(m map[string]interface{}, path string) interface{} {
 	}
 	return sub
 }
+
+// applyMath applies a validated math expression like *2 or /1,5 to value, as the database does for queried columns.
+// Numbers decoded from JSON are floats and the column type is unknown. The database truncates the results of integer columns,
+// divisions by integers as well as fractions of numeric results, so fractional results of integers are not cachable.
+// Values the database can't calculate with are not cachable, the database reports the error.
+func applyMath(value interface{}, math string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	number, ok := value.(float64)
+	if !ok || len(math) < 2 {
+		return nil, NotCachableError
+	}
+	operand, err := strconv.ParseFloat(strings.ReplaceAll(math[1:], ",", "."), 64)
+	if err != nil {
+		return nil, err
+	}
+	var result float64
+	switch math[0] {
+	case '+':
+		result = number + operand
+	case '-':
+		result = number - operand
+	case '*':
+		result = number * operand
+	case '/':
+		if operand == 0 {
+			return nil, NotCachableError
+		}
+		result = number / operand
+	default:
+		return nil, NotCachableError
+	}
+	if number == float64(int64(number)) && result != float64(int64(result)) {
+		return nil, NotCachableError
+	}
+	return result, nil
+}
@@ -17,9 +17,17 @@
 package cache
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	util "github.com/SENERGY-Platform/timescale-tableworker/pkg/lib/handler"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
 )
 
 func TestDeepEntryInLists(t *testing.T) {
@@ -51,3 +59,135 @@ func TestDeepEntryInLists(t *testing.T) {
 		return
 	}
 }
+
+func TestWriteBackLastMessage(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend(0)
+	rc := NewRemoteWithBackend(&configuration.ConfigStruct{}, backend, nil, nil)
+	deviceId := "device"
+	serviceId := "service"
+	one := 1
+	request := model.QueriesRequestElement{
+		DeviceId:  &deviceId,
+		ServiceId: &serviceId,
+		Limit:     &one,
+		Columns:   []model.QueriesRequestElementColumn{{Name: "metrics.level"}, {Name: "missing"}},
+	}
+
+	t.Run("stored with marker", func(t *testing.T) {
+		entry := Entry{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Value: map[string]interface{}{"metrics": map[string]interface{}{"level": 42.0}}}
+		err := rc.WriteBackLastMessage(ctx, deviceId, serviceId, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cached, err := rc.GetLastMessageFromCache(ctx, deviceId, serviceId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cached.WrittenBack || !cached.Time.Equal(entry.Time) {
+			t.Error("unexpected entry", cached)
+		}
+		values, err := rc.GetLastValuesFromCache(ctx, request, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(values, [][]interface{}{{entry.Time, 42.0, nil}}) {
+			t.Error("unexpected values", values)
+		}
+	})
+
+	t.Run("existing entry kept", func(t *testing.T) {
+		newer := Entry{Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Value: map[string]interface{}{}}
+		value, _ := json.Marshal(newer)
+		err := backend.Set(ctx, "device_"+deviceId+"_service_"+serviceId, value, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = rc.WriteBackLastMessage(ctx, deviceId, serviceId, Entry{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cached, err := rc.GetLastMessageFromCache(ctx, deviceId, serviceId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cached.WrittenBack || !cached.Time.Equal(newer.Time) {
+			t.Error("entry was overwritten", cached)
+		}
+	})
+}
+
+func TestLastValuesFromCacheExport(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend(0)
+	rc := NewRemoteWithBackend(&configuration.ConfigStruct{}, backend, nil, nil)
+	exportId := "export"
+	longName := "a_column_name_which_is_longer_than_the_sixty_three_bytes_postgres_allows"
+	entry := Entry{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Value: map[string]interface{}{
+		"value.power": 1500.5,
+		"sensor":      "s1",
+	}}
+	entry.Value[strings.Trim(util.HashFieldNameIfNeeded(longName), "\"")] = 2.0 // as read from the database
+	err := rc.WriteBackLastExportMessage(ctx, exportId, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := 1
+	kilo := "/1000"
+	double := "*2"
+	request := model.QueriesRequestElement{
+		ExportId: &exportId,
+		Limit:    &one,
+		Columns:  []model.QueriesRequestElementColumn{{Name: "value.power", Math: &kilo}, {Name: "sensor"}, {Name: longName, Math: &double}},
+	}
+	values, err := rc.GetLastValuesFromCache(ctx, request, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, [][]interface{}{{entry.Time, 1.5005, "s1", 4.0}}) {
+		t.Error("unexpected values", values)
+	}
+
+	request.Columns = []model.QueriesRequestElementColumn{{Name: "sensor", Math: &double}}
+	_, err = rc.GetLastValuesFromCache(ctx, request, nil)
+	if err != NotCachableError {
+		t.Error("expected not cachable for math on strings", err)
+	}
+
+	err = rc.DeleteLastExportMessage(ctx, exportId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rc.GetLastValuesFromCache(ctx, request, nil)
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("expected miss", err)
+	}
+}
+
+func TestApplyMath(t *testing.T) {
+	for _, tc := range []struct {
+		value    interface{}
+		math     string
+		expected interface{}
+		err      bool
+	}{
+		{value: 3.0, math: "+1", expected: 4.0},
+		{value: 3.0, math: "-1", expected: 2.0},
+		{value: 3.5, math: "*1,5", expected: 5.25},
+		{value: 4.0, math: "*0.5", expected: 2.0},
+		{value: 3.5, math: "/2", expected: 1.75},
+		{value: 3.0, math: "/1.5", expected: 2.0},
+		{value: 4.0, math: "/2", expected: 2.0},
+		{value: nil, math: "*2", expected: nil},
+		{value: 3.0, math: "/2", err: true},   // 1 for integer columns
+		{value: 3.0, math: "*1,5", err: true}, // 4 for integer columns
+		{value: 3.0, math: "+0.5", err: true}, // 3 for integer columns
+		{value: 3.0, math: "/0", err: true},
+		{value: true, math: "*2", err: true},
+	} {
+		result, err := applyMath(tc.value, tc.math)
+		if (err != nil) != tc.err || result != tc.expected {
+			t.Error("unexpected result", tc.value, tc.math, result, err)
+		}
+	}
+}
@@ -17,6 +17,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,17 +28,38 @@ import (
 )
 
 type Client interface {
-	GetDeviceUsage(token string, deviceIds []string) (result []Usage, code int, err error)
-	GetExportUsage(token string, exportIds []string) (result []Usage, code int, err error)
-	GetQueriesV2(token string, requestElements []QueriesRequestElement, options *QueriesV2Options) (result []QueriesV2ResponseElement, code int, err error)
+	GetDeviceUsage(ctx context.Context, token string, deviceIds []string) (result []Usage, code int, err error)
+	GetExportUsage(ctx context.Context, token string, exportIds []string) (result []Usage, code int, err error)
+	GetQueriesV2(ctx context.Context, token string, requestElements []QueriesRequestElement, options *QueriesV2Options) (result []QueriesV2ResponseElement, code int, err error)
+	ExplainQueriesV2(ctx context.Context, token string, requestElements []QueriesRequestElement, options *QueriesV2Options) (result []QueriesV2DryRunResponseElement, code int, err error)
+	GetQueries(ctx context.Context, token string, requestElements []QueriesRequestElement, options *QueriesOptions) (result [][][]interface{}, code int, err error)
+	GetQueriesAsTable(ctx context.Context, token string, requestElements []QueriesRequestElement, options *QueriesOptions) (result [][]interface{}, code int, err error)
+	GetLastValues(ctx context.Context, token string, requestElements []LastValuesRequestElement, timeFormat *string) (result []LastValuesResponseElement, code int, err error)
+	GetRawValue(ctx context.Context, token string, requestElement LastValuesRequestElement) (result interface{}, code int, err error)
+	GetLastMessage(ctx context.Context, token string, deviceId string, serviceId string) (result LastMessage, code int, err error)
+	GetDataAvailability(ctx context.Context, token string, deviceId string) (result []DataAvailabilityResponseElement, code int, err error)
+	PrepareDownload(ctx context.Context, token string, requestElement QueriesRequestElement, options *DownloadOptions) (secret string, code int, err error)
+	Download(ctx context.Context, token string, requestElement QueriesRequestElement, options *DownloadOptions) (file io.ReadCloser, code int, err error)
+	DownloadWithSecret(ctx context.Context, secret string) (file io.ReadCloser, code int, err error)
+	InsertRows(ctx context.Context, token string, request WriteRequest) (result WriteResponse, code int, err error)
+	UpsertRows(ctx context.Context, token string, request WriteRequest) (result WriteResponse, code int, err error)
+	DeleteRows(ctx context.Context, token string, request DeleteRowsRequest) (result WriteResponse, code int, err error)
+	PurgeData(ctx context.Context, token string, request PurgeRequest) (result PurgeResponse, code int, err error)
 }
 
 type impl struct {
-	baseUrl string
+	baseUrl     string
+	downloadUrl string
 }
 
 func NewClient(baseUrl string) Client {
-	return &impl{baseUrl: baseUrl}
+	return &impl{baseUrl: baseUrl, downloadUrl: baseUrl}
+}
+
+// NewClientWithDownloadUrl creates a client that uses downloadUrl for DownloadWithSecret.
+// The endpoint is served by the unauthenticated api, which usually has a different url.
+func NewClientWithDownloadUrl(baseUrl string, downloadUrl string) Client {
+	return &impl{baseUrl: baseUrl, downloadUrl: downloadUrl}
 }
 
 func do[T any](req *http.Request) (result T, code int, err error) {
@@ -58,6 +80,33 @@ func do[T any](req *http.Request) (result T, code int, err error) {
 	return result, resp.StatusCode, nil
 }
 
+// doStream returns the response body, which has to be closed by the caller
+func doStream(req *http.Request) (body io.ReadCloser, code int, err error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		temp, _ := io.ReadAll(resp.Body) //read error response end ensure that resp.Body is read to EOF
+		return nil, resp.StatusCode, GetErrFromCode(resp.StatusCode, string(temp))
+	}
+	return resp.Body, resp.StatusCode, nil
+}
+
+func doRaw(req *http.Request) (result []byte, code int, err error) {
+	body, code, err := doStream(req)
+	if err != nil {
+		return nil, code, err
+	}
+	defer body.Close()
+	result, err = io.ReadAll(body)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return result, code, nil
+}
+
 var ErrNotFound = errors.New("not found")
 var ErrAccessDenied = errors.New("access denied")
 var ErrBadRequest = errors.New("bad request")
@@ -0,0 +1,292 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/converter/lib/converter"
+	deviceSelection "github.com/SENERGY-Platform/device-selection/pkg/client"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/api"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/client"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
+	"github.com/golang-jwt/jwt"
+)
+
+const deviceId = "urn:infai:ses:device:ade1fba6-fa5f-4704-9997-81dc168f62f4"
+const serviceId = "urn:infai:ses:service:97805820-ca0a-46c5-9dcf-16c2e386b050"
+
+// TestClient runs the client against the real routers. Permissions and device selection are replaced by fakes, the cache is in memory.
+// No database is available, so requests are either answered from the cache or fail before a query is executed.
+func TestClient(t *testing.T) {
+	log.InitForTest()
+
+	ctx := context.Background()
+	backend := cache.NewMemoryBackend(0)
+	set := func(key string, value []byte) {
+		err := backend.Set(ctx, key, value, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	entry, _ := json.Marshal(cache.Entry{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Value: map[string]interface{}{"energy": map[string]interface{}{"total": 42}}})
+	set("device_"+deviceId+"_service_"+serviceId, entry)
+	deviceGroup, _ := json.Marshal(models.DeviceGroup{Id: "group", DeviceIds: []string{}})
+	set("device_group_group", deviceGroup)
+	function, _ := json.Marshal(models.Function{Id: "function", ConceptId: "concept"})
+	set("function_function", function)
+
+	permissions := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(!strings.Contains(r.URL.Path, "denied"))
+	}))
+	defer permissions.Close()
+	selection := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer selection.Close()
+
+	config := &configuration.ConfigStruct{
+		PermissionsUrl:     permissions.URL,
+		DeviceSelectionUrl: selection.URL,
+		DefaultTimezone:    "Europe/Berlin",
+	}
+	conv, err := converter.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	selectionClient := deviceSelection.NewClient(config.DeviceSelectionUrl)
+	verifier := verification.New(config)
+	remoteCache := cache.NewRemoteWithBackend(config, backend, nil, selectionClient)
+	server := httptest.NewServer(api.Router(config, nil, verifier, remoteCache, conv, selectionClient))
+	defer server.Close()
+	unauthenticatedServer := httptest.NewServer(api.UnauthenticatedRouter(config, nil, verifier, remoteCache, conv, selectionClient))
+	defer unauthenticatedServer.Close()
+
+	c := client.NewClientWithDownloadUrl(server.URL, unauthenticatedServer.URL)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user"}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token = "Bearer " + token
+
+	dId := deviceId
+	sId := serviceId
+	denied := "denied"
+	one := 1
+	cachable := client.QueriesRequestElement{
+		DeviceId:  &dId,
+		ServiceId: &sId,
+		Limit:     &one,
+		Columns:   []model.QueriesRequestElementColumn{{Name: "energy.total"}},
+	}
+	start := "2024-01-01T00:00:00Z"
+	end := "2024-01-02T00:00:00Z"
+	group := "group"
+	groupRequest := client.QueriesRequestElement{
+		DeviceGroupId: &group,
+		Time:          &model.QueriesRequestElementTime{Start: &start, End: &end},
+		Columns:       []model.QueriesRequestElementColumn{{Criteria: models.DeviceGroupFilterCriteria{FunctionId: "function", AspectId: "aspect"}}},
+	}
+
+	t.Run("GetQueries", func(t *testing.T) {
+		result, _, err := c.GetQueries(ctx, token, []client.QueriesRequestElement{cachable}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 1 || len(result[0]) != 1 || result[0][0][1] != float64(42) {
+			t.Error("unexpected result", result)
+		}
+	})
+
+	t.Run("GetQueriesAsTable", func(t *testing.T) {
+		result, _, err := c.GetQueriesAsTable(ctx, token, []client.QueriesRequestElement{cachable}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 1 || result[0][1] != float64(42) {
+			t.Error("unexpected result", result)
+		}
+	})
+
+	t.Run("GetQueriesV2", func(t *testing.T) {
+		result, _, err := c.GetQueriesV2(ctx, token, []client.QueriesRequestElement{cachable}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 1 || len(result[0].Data) != 1 || result[0].Data[0][0][1] != float64(42) {
+			t.Error("unexpected result", result)
+		}
+		_, code, err := c.GetQueriesV2(ctx, token, []client.QueriesRequestElement{{DeviceId: &denied, ServiceId: &sId, Columns: cachable.Columns}}, nil)
+		if !errors.Is(err, client.ErrNotFound) || code != http.StatusNotFound {
+			t.Error("expected not found", code, err)
+		}
+	})
+
+	t.Run("ExplainQueriesV2", func(t *testing.T) {
+		_, code, err := c.ExplainQueriesV2(ctx, token, []client.QueriesRequestElement{{DeviceId: &dId}}, nil)
+		if !errors.Is(err, client.ErrBadRequest) || code != http.StatusBadRequest {
+			t.Error("expected bad request", code, err)
+		}
+	})
+
+	t.Run("GetLastValues", func(t *testing.T) {
+		result, _, err := c.GetLastValues(ctx, token, []client.LastValuesRequestElement{{DeviceId: &dId, ServiceId: &sId, ColumnName: "energy.total"}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 1 || result[0].Value != float64(42) || result[0].Time == nil || *result[0].Time != "2024-01-01T00:00:00Z" {
+			t.Error("unexpected result", result)
+		}
+	})
+
+	t.Run("GetRawValue", func(t *testing.T) {
+		result, _, err := c.GetRawValue(ctx, token, client.LastValuesRequestElement{DeviceId: &dId, ServiceId: &sId, ColumnName: "energy.total"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != float64(42) {
+			t.Error("unexpected result", result)
+		}
+	})
+
+	t.Run("GetLastMessage", func(t *testing.T) {
+		result, _, err := c.GetLastMessage(ctx, token, deviceId, serviceId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Time.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || result.Value["energy"] == nil {
+			t.Error("unexpected result", result)
+		}
+		_, code, err := c.GetLastMessage(ctx, token, deviceId, "")
+		if !errors.Is(err, client.ErrBadRequest) || code != http.StatusBadRequest {
+			t.Error("expected bad request", code, err)
+		}
+	})
+
+	t.Run("GetDataAvailability", func(t *testing.T) {
+		_, code, err := c.GetDataAvailability(ctx, token, denied)
+		if !errors.Is(err, client.ErrNotFound) || code != http.StatusNotFound {
+			t.Error("expected not found", code, err)
+		}
+	})
+
+	t.Run("GetDeviceUsage", func(t *testing.T) {
+		_, code, err := c.GetDeviceUsage(ctx, token, []string{denied})
+		if !errors.Is(err, client.ErrNotFound) || code != http.StatusNotFound {
+			t.Error("expected not found", code, err)
+		}
+	})
+
+	t.Run("GetExportUsage", func(t *testing.T) {
+		_, code, err := c.GetExportUsage(ctx, token, []string{denied})
+		if !errors.Is(err, client.ErrNotFound) || code != http.StatusNotFound {
+			t.Error("expected not found", code, err)
+		}
+	})
+
+	t.Run("InsertRows", func(t *testing.T) {
+		deniedDevice := "urn:infai:ses:device:denied"
+		_, code, err := c.InsertRows(ctx, token, client.WriteRequest{DeviceId: &deniedDevice, ServiceId: &sId, Columns: []string{"energy.total"}, Rows: [][]interface{}{{start, 1}}})
+		if !errors.Is(err, client.ErrAccessDenied) || code != http.StatusForbidden {
+			t.Error("expected forbidden", code, err)
+		}
+		_, code, err = c.UpsertRows(ctx, token, client.WriteRequest{DeviceId: &deniedDevice, ServiceId: &sId, Columns: []string{"energy.total"}, Rows: [][]interface{}{{start, 1}}})
+		if !errors.Is(err, client.ErrAccessDenied) || code != http.StatusForbidden {
+			t.Error("expected forbidden", code, err)
+		}
+	})
+
+	t.Run("DeleteRows", func(t *testing.T) {
+		deniedDevice := "urn:infai:ses:device:denied"
+		_, code, err := c.DeleteRows(ctx, token, client.DeleteRowsRequest{DeviceId: &deniedDevice, ServiceId: &sId, Start: start, End: end})
+		if !errors.Is(err, client.ErrAccessDenied) || code != http.StatusForbidden {
+			t.Error("expected forbidden", code, err)
+		}
+	})
+
+	t.Run("PurgeData", func(t *testing.T) {
+		deniedDevice := "urn:infai:ses:device:denied"
+		_, code, err := c.PurgeData(ctx, token, client.PurgeRequest{DeviceId: &deniedDevice})
+		if !errors.Is(err, client.ErrAccessDenied) || code != http.StatusForbidden {
+			t.Error("expected forbidden", code, err)
+		}
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		file, _, err := c.Download(ctx, token, groupRequest, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "time,device_id,service_id,column,value\n" {
+			t.Error("unexpected content", string(content))
+		}
+		_, code, err := c.Download(ctx, token, client.QueriesRequestElement{DeviceId: &dId}, nil)
+		if !errors.Is(err, client.ErrBadRequest) || code != http.StatusBadRequest {
+			t.Error("expected bad request", code, err)
+		}
+	})
+
+	t.Run("PrepareDownload and DownloadWithSecret", func(t *testing.T) {
+		secret, _, err := c.PrepareDownload(ctx, token, groupRequest, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, _, err := c.DownloadWithSecret(ctx, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "time,device_id,service_id,column,value\n" {
+			t.Error("unexpected content", string(content))
+		}
+		_, code, err := c.DownloadWithSecret(ctx, secret) // secrets are deleted after use
+		if !errors.Is(err, client.ErrNotFound) || code != http.StatusNotFound {
+			t.Error("expected not found", code, err)
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, _, err := c.GetQueriesV2(canceled, token, []client.QueriesRequestElement{cachable}, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Error("expected canceled", err)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type DownloadOptions struct {
+	Format     *string // csv (default) or parquet
+	TimeFormat *string
+}
+
+// PrepareDownload returns a secret for DownloadWithSecret, which can be used without token for a short time
+func (c impl) PrepareDownload(ctx context.Context, token string, requestElement QueriesRequestElement, options *DownloadOptions) (secret string, code int, err error) {
+	req, err := c.newDownloadRequest(ctx, token, "/prepare-download", requestElement, options)
+	if err != nil {
+		return secret, 0, err
+	}
+	raw, code, err := doRaw(req)
+	return string(raw), code, err
+}
+
+// Download streams the file. The caller has to close it.
+func (c impl) Download(ctx context.Context, token string, requestElement QueriesRequestElement, options *DownloadOptions) (file io.ReadCloser, code int, err error) {
+	req, err := c.newDownloadRequest(ctx, token, "/download", requestElement, options)
+	if err != nil {
+		return nil, 0, err
+	}
+	return doStream(req)
+}
+
+// DownloadWithSecret streams the file prepared with PrepareDownload. The caller has to close it.
+func (c impl) DownloadWithSecret(ctx context.Context, secret string) (file io.ReadCloser, code int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.downloadUrl+"/download/"+url.PathEscape(secret), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	return doStream(req)
+}
+
+func (c impl) newDownloadRequest(ctx context.Context, token string, path string, requestElement QueriesRequestElement, options *DownloadOptions) (req *http.Request, err error) {
+	query, err := json.Marshal(requestElement)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", token)
+
+	q := req.URL.Query()
+	q.Add("query", string(query))
+	if options != nil {
+		if options.Format != nil {
+			q.Add("format", *options.Format)
+		}
+		if options.TimeFormat != nil {
+			q.Add("time_format", *options.TimeFormat)
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+	return req, nil
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+func (c impl) GetLastMessage(ctx context.Context, token string, deviceId string, serviceId string) (result LastMessage, code int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl+"/last-message", nil)
+	if err != nil {
+		return result, 0, err
+	}
+
+	req.Header.Add("Authorization", token)
+
+	q := req.URL.Query()
+	q.Add("device_id", deviceId)
+	q.Add("service_id", serviceId)
+	req.URL.RawQuery = q.Encode()
+	return do[LastMessage](req)
+}
+
+func (c impl) GetDataAvailability(ctx context.Context, token string, deviceId string) (result []DataAvailabilityResponseElement, code int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl+"/data-availability", nil)
+	if err != nil {
+		return result, 0, err
+	}
+
+	req.Header.Add("Authorization", token)
+
+	q := req.URL.Query()
+	q.Add("device_id", deviceId)
+	req.URL.RawQuery = q.Encode()
+	return do[[]DataAvailabilityResponseElement](req)
+}
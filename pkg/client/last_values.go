@@ -0,0 +1,84 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+func (c impl) GetLastValues(ctx context.Context, token string, requestElements []LastValuesRequestElement, timeFormat *string) (result []LastValuesResponseElement, code int, err error) {
+	body, err := json.Marshal(requestElements)
+	if err != nil {
+		return result, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl+"/last-values", bytes.NewReader(body))
+	if err != nil {
+		return result, 0, err
+	}
+
+	req.Header.Add("Authorization", token)
+
+	if timeFormat != nil {
+		q := req.URL.Query()
+		q.Add("time_format", *timeFormat)
+		req.URL.RawQuery = q.Encode()
+	}
+	return do[[]LastValuesResponseElement](req)
+}
+
+// GetRawValue returns the last value of a single column.
+// The endpoint writes strings without JSON encoding, so values that are no valid JSON are returned as string.
+func (c impl) GetRawValue(ctx context.Context, token string, requestElement LastValuesRequestElement) (result interface{}, code int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl+"/raw-value", nil)
+	if err != nil {
+		return result, 0, err
+	}
+
+	req.Header.Add("Authorization", token)
+
+	q := req.URL.Query()
+	q.Add("column", requestElement.ColumnName)
+	optional := map[string]*string{
+		"export_id":                requestElement.ExportId,
+		"device_id":                requestElement.DeviceId,
+		"service_id":               requestElement.ServiceId,
+		"math":                     requestElement.Math,
+		"source_characteristic_id": requestElement.SourceCharacteristicId,
+		"target_characteristic_id": requestElement.TargetCharacteristicId,
+		"concept_id":               requestElement.ConceptId,
+	}
+	for key, value := range optional {
+		if value != nil {
+			q.Add(key, *value)
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+
+	raw, code, err := doRaw(req)
+	if err != nil {
+		return result, code, err
+	}
+	err = json.Unmarshal(raw, &result)
+	if err != nil {
+		return string(raw), code, nil
+	}
+	return result, code, nil
+}
@@ -16,8 +16,27 @@
 
 package client
 
-import "github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+import (
+	"time"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+)
 
 type Usage = model.Usage
 type QueriesRequestElement = model.QueriesRequestElement
 type QueriesV2ResponseElement = model.QueriesV2ResponseElement
+type QueriesV2DryRunResponseElement = model.QueriesV2DryRunResponseElement
+type LastValuesRequestElement = model.LastValuesRequestElement
+type LastValuesResponseElement = model.LastValuesResponseElement
+type DataAvailabilityResponseElement = model.DataAvailabilityResponseElement
+type WriteRequest = model.WriteRequest
+type DeleteRowsRequest = model.DeleteRowsRequest
+type WriteResponse = model.WriteResponse
+type PurgeRequest = model.PurgeRequest
+type PurgeResponse = model.PurgeResponse
+
+// LastMessage is the last message of a device service, as returned by /last-message
+type LastMessage struct {
+	Time  time.Time              `json:"time"`
+	Value map[string]interface{} `json:"value"`
+}
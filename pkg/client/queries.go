@@ -0,0 +1,80 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// QueriesOptions are the query parameters of the deprecated /queries endpoint
+type QueriesOptions struct {
+	OrderColumnIndex *int
+	OrderDirection   *string
+	TimeFormat       *string
+}
+
+// GetQueries uses the deprecated /queries endpoint with format per_query
+func (c impl) GetQueries(ctx context.Context, token string, requestElements []QueriesRequestElement, options *QueriesOptions) (result [][][]interface{}, code int, err error) {
+	req, err := c.newQueriesRequest(ctx, token, requestElements, "per_query", options)
+	if err != nil {
+		return result, 0, err
+	}
+	return do[[][][]interface{}](req)
+}
+
+// GetQueriesAsTable uses the deprecated /queries endpoint with format table
+func (c impl) GetQueriesAsTable(ctx context.Context, token string, requestElements []QueriesRequestElement, options *QueriesOptions) (result [][]interface{}, code int, err error) {
+	req, err := c.newQueriesRequest(ctx, token, requestElements, "table", options)
+	if err != nil {
+		return result, 0, err
+	}
+	return do[[][]interface{}](req)
+}
+
+func (c impl) newQueriesRequest(ctx context.Context, token string, requestElements []QueriesRequestElement, format string, options *QueriesOptions) (req *http.Request, err error) {
+	body, err := json.Marshal(requestElements)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl+"/queries", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", token)
+
+	q := req.URL.Query()
+	q.Add("format", format)
+	if options != nil {
+		if options.OrderColumnIndex != nil {
+			q.Add("order_column_index", strconv.Itoa(*options.OrderColumnIndex))
+		}
+		if options.OrderDirection != nil {
+			q.Add("order_direction", *options.OrderDirection)
+		}
+		if options.TimeFormat != nil {
+			q.Add("time_format", *options.TimeFormat)
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+	return req, nil
+}
@@ -18,6 +18,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -33,16 +34,35 @@ type QueriesV2Options struct {
 	ForceTz          *string
 }
 
-func (c impl) GetQueriesV2(token string, requestElements []QueriesRequestElement, options *QueriesV2Options) (result []QueriesV2ResponseElement, code int, err error) {
-	body, err := json.Marshal(requestElements)
+func (c impl) GetQueriesV2(ctx context.Context, token string, requestElements []QueriesRequestElement, options *QueriesV2Options) (result []QueriesV2ResponseElement, code int, err error) {
+	req, err := c.newQueriesV2Request(ctx, token, requestElements, options)
 	if err != nil {
 		return result, 0, err
 	}
+	return do[[]QueriesV2ResponseElement](req)
+}
 
-	req, err := http.NewRequest(http.MethodPost, c.baseUrl+"/queries/v2", bytes.NewReader(body))
+func (c impl) ExplainQueriesV2(ctx context.Context, token string, requestElements []QueriesRequestElement, options *QueriesV2Options) (result []QueriesV2DryRunResponseElement, code int, err error) {
+	req, err := c.newQueriesV2Request(ctx, token, requestElements, options)
 	if err != nil {
 		return result, 0, err
 	}
+	q := req.URL.Query()
+	q.Set("dry_run", "true")
+	req.URL.RawQuery = q.Encode()
+	return do[[]QueriesV2DryRunResponseElement](req)
+}
+
+func (c impl) newQueriesV2Request(ctx context.Context, token string, requestElements []QueriesRequestElement, options *QueriesV2Options) (req *http.Request, err error) {
+	body, err := json.Marshal(requestElements)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl+"/queries/v2", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
 
 	req.Header.Add("Authorization", token)
 
@@ -71,6 +91,5 @@ func (c impl) GetQueriesV2(token string, requestElements []QueriesRequestElement
 		}
 	}
 	req.URL.RawQuery = q.Encode()
-
-	return do[[]QueriesV2ResponseElement](req)
+	return req, nil
 }
@@ -18,17 +18,18 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 )
 
-func (c impl) GetDeviceUsage(token string, deviceIds []string) (result []Usage, code int, err error) {
+func (c impl) GetDeviceUsage(ctx context.Context, token string, deviceIds []string) (result []Usage, code int, err error) {
 	body, err := json.Marshal(deviceIds)
 	if err != nil {
 		return result, 0, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.baseUrl+"/usage/devices", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl+"/usage/devices", bytes.NewReader(body))
 	if err != nil {
 		return result, 0, err
 	}
@@ -38,13 +39,13 @@ func (c impl) GetDeviceUsage(token string, deviceIds []string) (result []Usage,
 	return do[[]Usage](req)
 }
 
-func (c impl) GetExportUsage(token string, exportIds []string) (result []Usage, code int, err error) {
+func (c impl) GetExportUsage(ctx context.Context, token string, exportIds []string) (result []Usage, code int, err error) {
 	body, err := json.Marshal(exportIds)
 	if err != nil {
 		return result, 0, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.baseUrl+"/usage/exports", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl+"/usage/exports", bytes.NewReader(body))
 	if err != nil {
 		return result, 0, err
 	}
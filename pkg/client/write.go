@@ -0,0 +1,68 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+func (c impl) InsertRows(ctx context.Context, token string, request WriteRequest) (result WriteResponse, code int, err error) {
+	return writeRows[WriteRequest](ctx, token, http.MethodPost, c.baseUrl+"/rows", request)
+}
+
+func (c impl) UpsertRows(ctx context.Context, token string, request WriteRequest) (result WriteResponse, code int, err error) {
+	return writeRows[WriteRequest](ctx, token, http.MethodPut, c.baseUrl+"/rows", request)
+}
+
+func (c impl) DeleteRows(ctx context.Context, token string, request DeleteRowsRequest) (result WriteResponse, code int, err error) {
+	return writeRows[DeleteRowsRequest](ctx, token, http.MethodDelete, c.baseUrl+"/rows", request)
+}
+
+func writeRows[T any](ctx context.Context, token string, method string, url string, request T) (result WriteResponse, code int, err error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return result, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return result, 0, err
+	}
+
+	req.Header.Add("Authorization", token)
+
+	return do[WriteResponse](req)
+}
+
+func (c impl) PurgeData(ctx context.Context, token string, request PurgeRequest) (result PurgeResponse, code int, err error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return result, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.baseUrl+"/purge", bytes.NewReader(body))
+	if err != nil {
+		return result, 0, err
+	}
+
+	req.Header.Add("Authorization", token)
+
+	return do[PurgeResponse](req)
+}
@@ -0,0 +1,143 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package columnar
+
+import (
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+const ArrowStreamContentType = "application/vnd.apache.arrow.stream"
+
+// ArrowWriter writes rows in the Arrow IPC streaming format, each call of WriteRows produces one record batch
+type ArrowWriter struct {
+	columns []Column
+	schema  *arrow.Schema
+	mem     memory.Allocator
+	w       *ipc.Writer
+}
+
+func NewArrowWriter(writer io.Writer, columns []Column) *ArrowWriter {
+	schema := arrowSchema(columns)
+	mem := memory.NewGoAllocator()
+	return &ArrowWriter{
+		columns: columns,
+		schema:  schema,
+		mem:     mem,
+		w:       ipc.NewWriter(writer, ipc.WithSchema(schema), ipc.WithAllocator(mem)),
+	}
+}
+
+func (w *ArrowWriter) WriteRows(rows [][]interface{}) error {
+	record, err := newRecord(w.mem, w.schema, w.columns, rows)
+	if err != nil {
+		return err
+	}
+	defer record.Release()
+	return w.w.Write(record)
+}
+
+// Close writes the end of stream marker, the schema is written even if no rows were written
+func (w *ArrowWriter) Close() error {
+	return w.w.Close()
+}
+
+func arrowSchema(columns []Column) *arrow.Schema {
+	fields := make([]arrow.Field, len(columns))
+	for i, column := range columns {
+		fields[i] = arrow.Field{Name: column.Name, Type: arrowType(column.Type), Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// newRecord converts rows to a record batch of schema, values are converted to the types of columns
+func newRecord(mem memory.Allocator, schema *arrow.Schema, columns []Column, rows [][]interface{}) (arrow.RecordBatch, error) {
+	builder := array.NewRecordBuilder(mem, schema)
+	defer builder.Release()
+	for _, row := range rows {
+		for i, column := range columns {
+			var value interface{}
+			if i < len(row) {
+				value = row[i]
+			}
+			err := appendArrowValue(builder.Field(i), column.Type, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return builder.NewRecordBatch(), nil
+}
+
+func arrowType(t Type) arrow.DataType {
+	switch t {
+	case Timestamp:
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	case Int64:
+		return arrow.PrimitiveTypes.Int64
+	case Float64:
+		return arrow.PrimitiveTypes.Float64
+	case Bool:
+		return arrow.FixedWidthTypes.Boolean
+	default:
+		return arrow.BinaryTypes.String
+	}
+}
+
+func appendArrowValue(builder array.Builder, t Type, value interface{}) error {
+	if value == nil {
+		builder.AppendNull()
+		return nil
+	}
+	switch t {
+	case Timestamp:
+		v, err := toTime(value)
+		if err != nil {
+			return err
+		}
+		builder.(*array.TimestampBuilder).Append(arrow.Timestamp(v.UnixMicro()))
+	case Int64:
+		v, err := toInt64(value)
+		if err != nil {
+			return err
+		}
+		builder.(*array.Int64Builder).Append(v)
+	case Float64:
+		v, err := toFloat64(value)
+		if err != nil {
+			return err
+		}
+		builder.(*array.Float64Builder).Append(v)
+	case Bool:
+		v, err := toBool(value)
+		if err != nil {
+			return err
+		}
+		builder.(*array.BooleanBuilder).Append(v)
+	default:
+		v, err := toString(value)
+		if err != nil {
+			return err
+		}
+		builder.(*array.StringBuilder).Append(v)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package columnar
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+func TestColumnar(t *testing.T) {
+	t.Parallel()
+	t1, _ := time.Parse(time.RFC3339, "2022-12-06T06:00:00Z")
+	columns := []Column{{Name: "time", Type: Timestamp}, {Name: "value", Type: Float64}, {Name: "count", Type: Int64}, {Name: "label", Type: String}, {Name: "on", Type: Bool}}
+	rows := [][]interface{}{
+		{t1, 1.5, int64(1), "a", true},
+		{t1.Add(time.Second), nil, int32(2), nil, false},
+		{t1.Add(2 * time.Second), int64(3), nil, map[string]int{"b": 1}, nil},
+	}
+
+	t.Run("Test Types", func(t *testing.T) {
+		t.Parallel()
+		if TypeFromDataTypeName("timestamptz") != Timestamp || TypeFromDataTypeName("float8") != Float64 ||
+			TypeFromDataTypeName("numeric") != Int64 || TypeFromDataTypeName("jsonb") != String {
+			t.Error("unexpected type mapping")
+		}
+		if Common(Int64, Float64) != Float64 || Common(Int64, Int64) != Int64 || Common(Int64, Bool) != String || Common() != String {
+			t.Error("unexpected common type")
+		}
+		unique := UniqueNames([]Column{{Name: "a"}, {Name: "a"}, {Name: "b"}})
+		if unique[0].Name != "a" || unique[1].Name != "a_2" || unique[2].Name != "b" {
+			t.Error("unexpected names", unique)
+		}
+	})
+
+	t.Run("Test Arrow", func(t *testing.T) {
+		t.Parallel()
+		buf := bytes.Buffer{}
+		w := NewArrowWriter(&buf, columns)
+		err := w.WriteRows(rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := ipc.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Release()
+		if !r.Next() {
+			t.Fatal("expected record batch")
+		}
+		record := r.RecordBatch()
+		if record.NumRows() != 3 || record.NumCols() != 5 {
+			t.Fatal("unexpected shape", record.NumRows(), record.NumCols())
+		}
+		times := record.Column(0).(*array.Timestamp)
+		if times.Value(1) != arrow.Timestamp(t1.Add(time.Second).UnixMicro()) {
+			t.Error("unexpected timestamp", times.Value(1))
+		}
+		values := record.Column(1).(*array.Float64)
+		if values.Value(0) != 1.5 || !values.IsNull(1) || values.Value(2) != 3 {
+			t.Error("unexpected values")
+		}
+		counts := record.Column(2).(*array.Int64)
+		if counts.Value(1) != 2 || !counts.IsNull(2) {
+			t.Error("unexpected counts")
+		}
+		labels := record.Column(3).(*array.String)
+		if labels.Value(0) != "a" || !labels.IsNull(1) || labels.Value(2) != `{"b":1}` {
+			t.Error("unexpected labels")
+		}
+		if r.Next() {
+			t.Error("unexpected second record batch")
+		}
+	})
+
+	t.Run("Test Arrow Invalid Value", func(t *testing.T) {
+		t.Parallel()
+		w := NewArrowWriter(&bytes.Buffer{}, []Column{{Name: "count", Type: Int64}})
+		err := w.WriteRows([][]interface{}{{"not a number"}})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("Test Parquet", func(t *testing.T) {
+		t.Parallel()
+		buf := bytes.Buffer{}
+		w, err := NewParquetWriter(&buf, columns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.WriteRowGroup(rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.WriteRowGroup(rows[:1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		if reader.NumRowGroups() != 2 || reader.NumRows() != 4 {
+			t.Error("unexpected row groups", reader.NumRowGroups(), reader.NumRows())
+		}
+		fileReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		table, err := fileReader.ReadTable(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer table.Release()
+		if table.NumCols() != 5 || table.Schema().Field(3).Name != "label" {
+			t.Fatal("unexpected schema", table.Schema())
+		}
+		times := table.Column(0).Data().Chunk(0).(*array.Timestamp)
+		if times.Value(1) != arrow.Timestamp(t1.Add(time.Second).UnixMicro()) {
+			t.Error("unexpected timestamp", times.Value(1))
+		}
+		values := table.Column(1).Data().Chunk(0).(*array.Float64)
+		if values.Value(0) != 1.5 || !values.IsNull(1) || values.Value(2) != 3 || values.Value(3) != 1.5 {
+			t.Error("unexpected values", values)
+		}
+		counts := table.Column(2).Data().Chunk(0).(*array.Int64)
+		if counts.Value(1) != 2 || !counts.IsNull(2) {
+			t.Error("unexpected counts", counts)
+		}
+		labels := table.Column(3).Data().Chunk(0).(*array.String)
+		if labels.Value(0) != "a" || !labels.IsNull(1) || labels.Value(2) != `{"b":1}` {
+			t.Error("unexpected labels", labels)
+		}
+		on := table.Column(4).Data().Chunk(0).(*array.Boolean)
+		if !on.Value(0) || on.Value(1) || !on.IsNull(2) {
+			t.Error("unexpected booleans", on)
+		}
+	})
+}
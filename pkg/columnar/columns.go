@@ -0,0 +1,177 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package columnar
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Type is the type of a column in columnar output formats
+type Type int
+
+const (
+	String Type = iota
+	Timestamp
+	Int64
+	Float64
+	Bool
+)
+
+type Column struct {
+	Name string
+	Type Type
+}
+
+// TypeFromDataTypeName maps a postgres type name, as reported in the field descriptions of a query, to a column type.
+// Numerics are mapped to Int64, because they are read as int64.
+func TypeFromDataTypeName(dataTypeName string) Type {
+	switch dataTypeName {
+	case "timestamptz", "timestamp":
+		return Timestamp
+	case "int2", "int4", "int8", "numeric":
+		return Int64
+	case "float4", "float8":
+		return Float64
+	case "bool":
+		return Bool
+	default:
+		return String
+	}
+}
+
+// TypeOf guesses the column type of a value. Used for values that don't come from the database, e.g. cached values.
+func TypeOf(value interface{}) (t Type, ok bool) {
+	switch value.(type) {
+	case nil:
+		return String, false
+	case time.Time:
+		return Timestamp, true
+	case int, int16, int32, int64:
+		return Int64, true
+	case float32, float64:
+		return Float64, true
+	case bool:
+		return Bool, true
+	default:
+		return String, true
+	}
+}
+
+// Common returns a type that can hold values of all given types
+func Common(types ...Type) Type {
+	if len(types) == 0 {
+		return String
+	}
+	common := types[0]
+	for _, t := range types[1:] {
+		if t == common {
+			continue
+		}
+		if (t == Int64 || t == Float64) && (common == Int64 || common == Float64) {
+			common = Float64
+			continue
+		}
+		return String
+	}
+	return common
+}
+
+// UniqueNames appends a suffix to duplicate column names, most readers can't handle duplicates
+func UniqueNames(columns []Column) []Column {
+	seen := map[string]int{}
+	result := make([]Column, len(columns))
+	for i, column := range columns {
+		seen[column.Name]++
+		if seen[column.Name] > 1 {
+			column.Name = column.Name + "_" + strconv.Itoa(seen[column.Name])
+		}
+		result[i] = column
+	}
+	return result
+}
+
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return time.Parse(time.RFC3339Nano, v)
+	default:
+		return time.Time{}, fmt.Errorf("can't use %T as timestamp", value)
+	}
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float32:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("can't use %T as int64", value)
+	}
+}
+
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("can't use %T as float64", value)
+	}
+}
+
+func toBool(value interface{}) (bool, error) {
+	v, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("can't use %T as bool", value)
+	}
+	return v, nil
+}
+
+func toString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package columnar
+
+import (
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+const ParquetContentType = "application/vnd.apache.parquet"
+
+// ParquetWriter writes rows as parquet file. Each call of WriteRowGroup produces one row group,
+// so rows can be written in chunks without keeping the whole file in memory.
+type ParquetWriter struct {
+	columns []Column
+	schema  *arrow.Schema
+	mem     memory.Allocator
+	w       *pqarrow.FileWriter
+}
+
+func NewParquetWriter(writer io.Writer, columns []Column) (*ParquetWriter, error) {
+	schema := arrowSchema(columns)
+	mem := memory.NewGoAllocator()
+	w, err := pqarrow.NewFileWriter(schema, writer,
+		parquet.NewWriterProperties(parquet.WithAllocator(mem), parquet.WithCompression(compress.Codecs.Snappy), parquet.WithCreatedBy("timescale-wrapper")),
+		pqarrow.NewArrowWriterProperties(pqarrow.WithAllocator(mem)))
+	if err != nil {
+		return nil, err
+	}
+	return &ParquetWriter{columns: columns, schema: schema, mem: mem, w: w}, nil
+}
+
+func (w *ParquetWriter) WriteRowGroup(rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	record, err := newRecord(w.mem, w.schema, w.columns, rows)
+	if err != nil {
+		return err
+	}
+	defer record.Release()
+	return w.w.Write(record)
+}
+
+// Close writes the file metadata. The file is invalid if Close is not called.
+func (w *ParquetWriter) Close() error {
+	return w.w.Close()
+}
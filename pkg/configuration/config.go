@@ -28,23 +28,48 @@ import (
 )
 
 type ConfigStruct struct {
-	ApiPort                string   `json:"api_port"`
-	UnauthenticatedApiPort string   `json:"unauthenticated_api_port"`
-	PostgresHost           string   `json:"postgres_host"`
-	PostgresPort           uint16   `json:"postgres_port"`
-	PostgresUser           string   `json:"postgres_user" config:"secret"`
-	PostgresDb             string   `json:"postgres_db"`
-	PostgresPw             string   `json:"postgres_pw" config:"secret"`
-	PostgresUsageSchema    string   `json:"postgres_usage_schema"`
-	PermissionsUrl         string   `json:"permissions_url"`
-	ServingUrl             string   `json:"serving_url"`
-	MemcachedUrls          []string `json:"memcached_urls"`
-	Debug                  bool     `json:"debug"`
-	DeviceRepoUrl          string   `json:"device_repo_url"`
-	DeviceSelectionUrl     string   `json:"device_selection_url"`
-	ImportRepoUrl          string   `json:"import_repo_url"`
-	DefaultTimezone        string   `json:"default_timezone"`
-	LogHandler             string   `json:"log_handler"`
+	ApiPort                    string            `json:"api_port"`
+	UnauthenticatedApiPort     string            `json:"unauthenticated_api_port"`
+	PostgresHost               string            `json:"postgres_host"`
+	PostgresPort               uint16            `json:"postgres_port"`
+	PostgresUser               string            `json:"postgres_user" config:"secret"`
+	PostgresDb                 string            `json:"postgres_db"`
+	PostgresPw                 string            `json:"postgres_pw" config:"secret"`
+	PostgresUsageSchema        string            `json:"postgres_usage_schema"`
+	PermissionsUrl             string            `json:"permissions_url"`
+	ServingUrl                 string            `json:"serving_url"`
+	CacheBackend               string            `json:"cache_backend"`
+	MemcachedUrls              []string          `json:"memcached_urls"`
+	RedisUrl                   string            `json:"redis_url" config:"secret"`
+	CacheMemorySize            int64             `json:"cache_memory_size"`
+	Debug                      bool              `json:"debug"`
+	DeviceRepoUrl              string            `json:"device_repo_url"`
+	DeviceSelectionUrl         string            `json:"device_selection_url"`
+	ImportRepoUrl              string            `json:"import_repo_url"`
+	DefaultTimezone            string            `json:"default_timezone"`
+	LogHandler                 string            `json:"log_handler"`
+	DownloadChunkRows          int64             `json:"download_chunk_rows"`
+	RequestTimeout             string            `json:"request_timeout"`
+	RateLimitRequestsPerSecond float64           `json:"rate_limit_requests_per_second"`
+	RateLimitBurst             int64             `json:"rate_limit_burst"`
+	RateLimitConcurrentQueries int64             `json:"rate_limit_concurrent_queries"`
+	RateLimitMaxRows           int64             `json:"rate_limit_max_rows"`
+	RateLimitMaxCost           float64           `json:"rate_limit_max_cost"`
+	PostgresMaxConnections     int64             `json:"postgres_max_connections"`
+	PostgresAcquireTimeout     string            `json:"postgres_acquire_timeout"`
+	DbMaxInFlight              int64             `json:"db_max_in_flight"`
+	DbQueueTimeout             string            `json:"db_queue_timeout"`
+	OtlpTracesEndpoint         string            `json:"otlp_traces_endpoint"`
+	TracingSampleRatio         float64           `json:"tracing_sample_ratio"`
+	HealthCheckUpstreams       bool              `json:"health_check_upstreams"`
+	ShutdownDelay              string            `json:"shutdown_delay"`
+	ShutdownTimeout            string            `json:"shutdown_timeout"`
+	CacheExpirations           map[string]string `json:"cache_expirations"`
+	CacheL1Size                int64             `json:"cache_l1_size"`
+	CacheL1Expiration          string            `json:"cache_l1_expiration"`
+	KafkaUrl                   string            `json:"kafka_url"`
+	CacheInvalidationTopics    map[string]string `json:"cache_invalidation_topics"`
+	CacheInvalidationSecret    string            `json:"cache_invalidation_secret" config:"secret"`
 }
 
 type Config = *ConfigStruct
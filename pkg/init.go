@@ -21,32 +21,70 @@ import (
 	"github.com/SENERGY-Platform/converter/lib/converter"
 	"github.com/SENERGY-Platform/device-repository/lib/client"
 	deviceSelectionClient "github.com/SENERGY-Platform/device-selection/pkg/client"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/api"
 	cache "github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/invalidation"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
 	"sync"
 )
 
+// Start starts the api. When ctx is done, the api is drained first, then the database pool is closed and remaining spans are flushed.
+// wg is done when the shutdown is complete.
 func Start(ctx context.Context, config configuration.Config) (wg *sync.WaitGroup, err error) {
 	wg = &sync.WaitGroup{}
-	wrapper, err := timescale.NewWrapper(ctx, wg, config)
+	shutdownTracing, err := tracing.Init(ctx, config)
+	if err != nil {
+		return wg, err
+	}
+	wrapper, err := timescale.NewWrapper(config)
 	if err != nil {
 		return wg, err
 	}
 	err = wrapper.Migrate()
 	if err != nil {
+		wrapper.Close()
 		return wg, err
 	}
 	verifier := verification.New(config)
 	deviceRepoClient := client.NewClient(config.DeviceRepoUrl, nil)
 	deviceSelection := deviceSelectionClient.NewClient(config.DeviceSelectionUrl)
-	lastValueCache := cache.NewRemote(config, deviceRepoClient, deviceSelection)
+	lastValueCache, err := cache.NewRemote(config, deviceRepoClient, deviceSelection)
+	if err != nil {
+		wrapper.Close()
+		return wg, err
+	}
+	err = invalidation.StartKafka(ctx, wg, config, invalidation.New(lastValueCache, verifier))
+	if err != nil {
+		wrapper.Close()
+		return wg, err
+	}
 	conv, err := converter.New()
 	if err != nil {
+		wrapper.Close()
+		return wg, err
+	}
+	apiWg := &sync.WaitGroup{}
+	err = api.Start(ctx, apiWg, config, wrapper, verifier, lastValueCache, conv, deviceSelection)
+	if err != nil {
+		wrapper.Close()
 		return wg, err
 	}
-	err = api.Start(ctx, wg, config, wrapper, verifier, lastValueCache, conv, deviceSelection)
-	return
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		apiWg.Wait()
+		log.Logger.Info("close database pool")
+		wrapper.Close()
+		err := shutdownTracing(context.Background())
+		if err != nil {
+			log.Logger.Warn("could not flush spans", attributes.ErrorKey, err)
+		}
+	}()
+	return wg, nil
 }
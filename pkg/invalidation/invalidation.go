@@ -0,0 +1,124 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package invalidation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
+)
+
+// Kind is the type of a changed entity. Kinds of cached entities match the cache names of RemoteCache.
+type Kind string
+
+const (
+	Device      Kind = "device"
+	DeviceGroup Kind = "device_group"
+	Location    Kind = "location"
+	Service     Kind = "service"
+	Function    Kind = "function"
+	Concept     Kind = "concept"
+	DeviceType  Kind = "device_type" // device types are not cached, but change selectables and their services
+	Export      Kind = "export"      // last messages and permissions of exports are cached
+)
+
+// Sources of events, used as metric labels
+const (
+	SourceKafka   = "kafka"
+	SourceWebhook = "webhook"
+	SourceLocal   = "local"
+)
+
+var ErrInvalidEvent = errors.New("invalid invalidation event")
+
+// Event notifies about a changed entity or changed permissions of an entity
+type Event struct {
+	Kind Kind   `json:"kind"`
+	Id   string `json:"id"`
+}
+
+func (event Event) Valid() bool {
+	return len(event.Id) > 0 && KnownKind(event.Kind)
+}
+
+func KnownKind(kind Kind) bool {
+	switch kind {
+	case Device, DeviceGroup, Location, Service, Function, Concept, DeviceType, Export:
+		return true
+	}
+	return false
+}
+
+// Invalidator evicts the entries affected by events from the remote cache and the permission cache of the verifier
+type Invalidator struct {
+	remoteCache *cache.RemoteCache
+	verifier    *verification.Verifier
+}
+
+func New(remoteCache *cache.RemoteCache, verifier *verification.Verifier) *Invalidator {
+	return &Invalidator{remoteCache: remoteCache, verifier: verifier}
+}
+
+// Invalidate handles events in order and stops at the first error. source labels the metrics.
+func (invalidator *Invalidator) Invalidate(ctx context.Context, source string, events ...Event) error {
+	for _, event := range events {
+		if !event.Valid() {
+			return errors.Join(fmt.Errorf("kind %#v, id %#v", event.Kind, event.Id), ErrInvalidEvent)
+		}
+		metrics.CacheInvalidations.WithLabelValues(string(event.Kind), source).Inc()
+		var err error
+		switch event.Kind {
+		case Device, DeviceGroup, Location:
+			// permission changes are published like other changes of the entity
+			invalidator.verifier.Invalidate(event.Id)
+			err = invalidator.remoteCache.Invalidate(ctx, string(event.Kind), event.Id)
+			if err == nil {
+				err = invalidator.remoteCache.InvalidateSelectables(ctx)
+			}
+		case Service, Function, Concept:
+			err = invalidator.remoteCache.Invalidate(ctx, string(event.Kind), event.Id)
+		case DeviceType:
+			err = invalidator.remoteCache.InvalidateSelectables(ctx)
+		case Export:
+			// changed columns would be missing in the cached last message
+			invalidator.verifier.Invalidate(event.Id)
+			err = invalidator.remoteCache.DeleteLastExportMessage(ctx, event.Id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Local delivers events directly to an Invalidator. Stands in for Kafka in tests and setups without it.
+type Local struct {
+	invalidator *Invalidator
+}
+
+func NewLocal(invalidator *Invalidator) *Local {
+	return &Local{invalidator: invalidator}
+}
+
+// Publish returns after the caches are invalidated
+func (local *Local) Publish(ctx context.Context, events ...Event) error {
+	return local.invalidator.Invalidate(ctx, SourceLocal, events...)
+}
@@ -0,0 +1,55 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package invalidation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/verification"
+)
+
+func TestLocal(t *testing.T) {
+	log.InitForTest()
+	ctx := context.Background()
+	config := &configuration.ConfigStruct{}
+	backend := cache.NewMemoryBackend(0)
+	err := backend.Set(ctx, "device_invalidated", []byte("{}"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidator := New(cache.NewRemoteWithBackend(config, backend, nil, nil), verification.New(config))
+	err = NewLocal(invalidator).Publish(ctx, Event{Kind: Device, Id: "invalidated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = backend.Get(ctx, "device_invalidated")
+	if err == nil {
+		t.Error("device still cached")
+	}
+	_, err = backend.Get(ctx, "selectables_generation")
+	if err != nil {
+		t.Error("expected new selectables generation", err)
+	}
+	err = NewLocal(invalidator).Publish(ctx, Event{Kind: "unknown", Id: "invalidated"})
+	if err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/segmentio/kafka-go"
+)
+
+// command is the part of the commands published by the device-repository that is needed for invalidation
+type command struct {
+	Command    string `json:"command"`
+	Id         string `json:"id"`
+	DeviceType *struct {
+		Services []struct {
+			Id string `json:"id"`
+		} `json:"services"`
+	} `json:"device_type,omitempty"`
+}
+
+// parseCommand returns the events of a message from a topic of kind. Commands of device types are expanded to their services.
+func parseCommand(kind Kind, message []byte) (events []Event, err error) {
+	var cmd command
+	err = json.Unmarshal(message, &cmd)
+	if err != nil {
+		return nil, err
+	}
+	events = []Event{{Kind: kind, Id: cmd.Id}}
+	if kind == DeviceType && cmd.DeviceType != nil {
+		for _, service := range cmd.DeviceType.Services {
+			events = append(events, Event{Kind: Service, Id: service.Id})
+		}
+	}
+	return events, nil
+}
+
+// StartKafka consumes the topics of config.CacheInvalidationTopics until ctx is done, wg is done after all readers are closed.
+// Does nothing without config.KafkaUrl. Every instance has to see all events, because all local caches have to be invalidated.
+// So each partition is read by its own reader without consumer group, which also leaves no stale groups behind after restarts.
+// Partitions added to a topic later are only read after a restart.
+func StartKafka(ctx context.Context, wg *sync.WaitGroup, config configuration.Config, invalidator *Invalidator) error {
+	if config.KafkaUrl == "" {
+		return nil
+	}
+	for topic, kind := range config.CacheInvalidationTopics {
+		if !KnownKind(Kind(kind)) {
+			return fmt.Errorf("unknown kind %#v for cache invalidation topic %#v", kind, topic)
+		}
+	}
+	for topic, kind := range config.CacheInvalidationTopics {
+		partitions, err := kafka.LookupPartitions(ctx, "tcp", config.KafkaUrl, topic)
+		if err != nil {
+			return fmt.Errorf("could not look up partitions of cache invalidation topic %#v: %w", topic, err)
+		}
+		for _, partition := range partitions {
+			reader := kafka.NewReader(kafka.ReaderConfig{
+				Brokers:   []string{config.KafkaUrl},
+				Topic:     topic,
+				Partition: partition.ID,
+				MaxWait:   time.Second,
+				ErrorLogger: kafka.LoggerFunc(func(msg string, args ...interface{}) {
+					log.Logger.Error(fmt.Sprintf(msg, args...), "topic", topic, "partition", partition.ID)
+				}),
+			})
+			err = reader.SetOffset(kafka.LastOffset) // caches of earlier changes have expired or are empty after a restart
+			if err != nil {
+				_ = reader.Close()
+				return err
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer reader.Close()
+				consume(ctx, reader, Kind(kind), invalidator)
+			}()
+		}
+	}
+	return nil
+}
+
+// consume invalidates the caches for each message of reader until ctx is done
+func consume(ctx context.Context, reader *kafka.Reader, kind Kind, invalidator *Invalidator) {
+	topic := reader.Config().Topic
+	for {
+		message, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Logger.Error("could not read cache invalidation", "topic", topic, attributes.ErrorKey, err)
+			time.Sleep(time.Second)
+			continue
+		}
+		events, err := parseCommand(kind, message.Value)
+		if err != nil {
+			log.Logger.Warn("could not parse cache invalidation", "topic", topic, attributes.ErrorKey, err)
+			continue
+		}
+		err = invalidator.Invalidate(ctx, SourceKafka, events...)
+		if err != nil {
+			log.Logger.Error("could not invalidate cache", "topic", topic, attributes.ErrorKey, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package invalidation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	events, err := parseCommand(Device, []byte(`{"command": "RIGHTS", "id": "urn:infai:ses:device:1", "owner": "user"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Event{{Kind: Device, Id: "urn:infai:ses:device:1"}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Error("Expected/Actual\n", expected, "\n", events)
+	}
+
+	events, err = parseCommand(DeviceType, []byte(`{"command": "PUT", "id": "dt", "device_type": {"id": "dt", "services": [{"id": "s1"}, {"id": "s2"}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Event{{Kind: DeviceType, Id: "dt"}, {Kind: Service, Id: "s1"}, {Kind: Service, Id: "s2"}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Error("Expected/Actual\n", expected, "\n", events)
+	}
+
+	_, err = parseCommand(Device, []byte("not json"))
+	if err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package limits
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/timescale"
+	"golang.org/x/time/rate"
+)
+
+// estimatedRawValueInterval is the assumed interval between raw values if the number of rows can't be derived from limits
+const estimatedRawValueInterval = time.Minute
+
+const pruneInterval = 10 * time.Minute
+
+// Limiter limits requests and concurrent queries of each user. Limits set to zero in the config are disabled.
+type Limiter struct {
+	config    configuration.Config
+	mux       sync.Mutex
+	users     map[string]*user
+	lastPrune time.Time
+}
+
+type user struct {
+	requests *rate.Limiter
+	queries  int
+	lastSeen time.Time
+}
+
+func New(config configuration.Config) *Limiter {
+	return &Limiter{config: config, users: map[string]*user{}, lastPrune: time.Now()}
+}
+
+// getUser has to be called with locked mux
+func (limiter *Limiter) getUser(userId string) *user {
+	now := time.Now()
+	if now.Sub(limiter.lastPrune) > pruneInterval {
+		for id, u := range limiter.users {
+			if u.queries == 0 && now.Sub(u.lastSeen) > pruneInterval {
+				delete(limiter.users, id)
+			}
+		}
+		limiter.lastPrune = now
+	}
+	u, ok := limiter.users[userId]
+	if !ok {
+		burst := int(limiter.config.RateLimitBurst)
+		if burst <= 0 {
+			burst = max(1, int(math.Ceil(limiter.config.RateLimitRequestsPerSecond)))
+		}
+		u = &user{requests: rate.NewLimiter(rate.Limit(limiter.config.RateLimitRequestsPerSecond), burst)}
+		limiter.users[userId] = u
+	}
+	u.lastSeen = now
+	return u
+}
+
+// Allow takes a request from the rate limit of the user. If the rate limit is exceeded,
+// it returns false and the time after which the request would be allowed.
+func (limiter *Limiter) Allow(userId string) (ok bool, retryAfter time.Duration) {
+	if limiter.config.RateLimitRequestsPerSecond <= 0 {
+		return true, 0
+	}
+	limiter.mux.Lock()
+	defer limiter.mux.Unlock()
+	reservation := limiter.getUser(userId).requests.Reserve()
+	delay := reservation.Delay()
+	if delay > 0 {
+		reservation.Cancel()
+		return false, delay
+	}
+	return true, 0
+}
+
+// AcquireQueries reserves n concurrent queries of the user. The returned release func has to be called when the queries are done.
+func (limiter *Limiter) AcquireQueries(userId string, n int) (ok bool, release func()) {
+	if limiter.config.RateLimitConcurrentQueries <= 0 || n <= 0 {
+		return true, func() {}
+	}
+	limiter.mux.Lock()
+	defer limiter.mux.Unlock()
+	u := limiter.getUser(userId)
+	if int64(u.queries+n) > limiter.config.RateLimitConcurrentQueries {
+		return false, nil
+	}
+	u.queries += n
+	once := sync.Once{}
+	return true, func() {
+		once.Do(func() {
+			limiter.mux.Lock()
+			defer limiter.mux.Unlock()
+			u.queries -= n
+		})
+	}
+}
+
+// AllowCost checks the estimated cost of a request against the configured maximum
+func (limiter *Limiter) AllowCost(cost float64) bool {
+	return limiter.config.RateLimitMaxCost <= 0 || cost <= limiter.config.RateLimitMaxCost
+}
+
+// EstimateCost estimates the number of values read for the request elements: the number of columns times the number of rows.
+// Rows of grouped elements are the time buckets in the time range. Rows of raw elements are limited by the limit or
+// estimated from the time range. Elements without time range and limit are unbounded and have an infinite cost.
+func EstimateCost(elements []model.QueriesRequestElement) (cost float64) {
+	for _, element := range elements {
+		cost += float64(len(element.Columns)) * estimateRows(element)
+	}
+	return cost
+}
+
+func estimateRows(element model.QueriesRequestElement) float64 {
+	rows := math.Inf(1)
+	if element.Limit != nil {
+		rows = float64(*element.Limit)
+	}
+	timeRange, ok := estimateTimeRange(element.Time)
+	if !ok {
+		return rows
+	}
+	interval := estimatedRawValueInterval
+	if element.GroupTime != nil {
+		groupTime, err := timescale.ApproxIntervalDuration(*element.GroupTime)
+		if err == nil && groupTime > 0 {
+			interval = groupTime
+		}
+	}
+	return min(rows, math.Max(1, math.Ceil(float64(timeRange)/float64(interval))))
+}
+
+func estimateTimeRange(elementTime *model.QueriesRequestElementTime) (timeRange time.Duration, ok bool) {
+	if elementTime == nil {
+		return 0, false
+	}
+	if elementTime.Last != nil || elementTime.Ahead != nil {
+		interval := elementTime.Last
+		if interval == nil {
+			interval = elementTime.Ahead
+		}
+		timeRange, err := timescale.ApproxIntervalDuration(*interval)
+		return timeRange, err == nil
+	}
+	if elementTime.Start == nil || elementTime.End == nil {
+		return 0, false
+	}
+	start, err := time.Parse(time.RFC3339, *elementTime.Start)
+	if err != nil {
+		return 0, false
+	}
+	end, err := time.Parse(time.RFC3339, *elementTime.End)
+	if err != nil {
+		return 0, false
+	}
+	return end.Sub(start), true
+}
@@ -0,0 +1,124 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package limits
+
+import (
+	"math"
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+)
+
+func TestEstimateCost(t *testing.T) {
+	start := "2024-01-01T00:00:00Z"
+	end := "2024-01-02T00:00:00Z"
+	last := "7d"
+	groupTime := "1h"
+	limit := 10
+	columns := []model.QueriesRequestElementColumn{{Name: "a"}, {Name: "b"}}
+
+	cases := []struct {
+		name     string
+		element  model.QueriesRequestElement
+		expected float64
+	}{
+		{"grouped", model.QueriesRequestElement{Columns: columns, GroupTime: &groupTime, Time: &model.QueriesRequestElementTime{Start: &start, End: &end}}, 2 * 24},
+		{"grouped last", model.QueriesRequestElement{Columns: columns, GroupTime: &groupTime, Time: &model.QueriesRequestElementTime{Last: &last}}, 2 * 24 * 7},
+		{"grouped with limit", model.QueriesRequestElement{Columns: columns, GroupTime: &groupTime, Limit: &limit, Time: &model.QueriesRequestElementTime{Start: &start, End: &end}}, 2 * 10},
+		{"raw", model.QueriesRequestElement{Columns: columns, Time: &model.QueriesRequestElementTime{Start: &start, End: &end}}, 2 * 24 * 60},
+		{"raw with limit", model.QueriesRequestElement{Columns: columns, Limit: &limit}, 2 * 10},
+		{"unbounded", model.QueriesRequestElement{Columns: columns}, math.Inf(1)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := EstimateCost([]model.QueriesRequestElement{tc.element})
+			if actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	limiter := New(&configuration.ConfigStruct{
+		RateLimitRequestsPerSecond: 1,
+		RateLimitBurst:             2,
+		RateLimitConcurrentQueries: 3,
+		RateLimitMaxCost:           100,
+	})
+
+	t.Run("requests", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			ok, _ := limiter.Allow("a")
+			if !ok {
+				t.Fatal("expected request within burst to be allowed")
+			}
+		}
+		ok, retryAfter := limiter.Allow("a")
+		if ok || retryAfter <= 0 {
+			t.Error("expected request to be limited with retry after", retryAfter)
+		}
+		ok, _ = limiter.Allow("b")
+		if !ok {
+			t.Error("expected other user not to be limited")
+		}
+	})
+
+	t.Run("queries", func(t *testing.T) {
+		ok, release := limiter.AcquireQueries("a", 2)
+		if !ok {
+			t.Fatal("expected queries to be acquired")
+		}
+		ok, _ = limiter.AcquireQueries("a", 2)
+		if ok {
+			t.Error("expected concurrent query limit to be exceeded")
+		}
+		ok, releaseOther := limiter.AcquireQueries("b", 3)
+		if !ok {
+			t.Error("expected other user not to be limited")
+		}
+		releaseOther()
+		release()
+		release() // releasing twice has no effect
+		ok, release = limiter.AcquireQueries("a", 3)
+		if !ok {
+			t.Error("expected released queries to be available")
+		}
+		release()
+	})
+
+	t.Run("cost", func(t *testing.T) {
+		if !limiter.AllowCost(100) || limiter.AllowCost(101) || limiter.AllowCost(math.Inf(1)) {
+			t.Error("unexpected cost check")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled := New(&configuration.ConfigStruct{})
+		for i := 0; i < 100; i++ {
+			ok, _ := disabled.Allow("a")
+			if !ok {
+				t.Fatal("expected disabled limiter to allow every request")
+			}
+		}
+		ok, _ := disabled.AcquireQueries("a", 1000)
+		if !ok || !disabled.AllowCost(math.Inf(1)) {
+			t.Error("expected disabled limiter to allow everything")
+		}
+	})
+}
@@ -0,0 +1,149 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "timescale_wrapper"
+
+// Upstream services
+const (
+	Permissions      = "permissions"
+	Serving          = "serving"
+	DeviceRepository = "device-repository"
+	DeviceSelection  = "device-selection"
+	ImportRepository = "import-repository"
+)
+
+var (
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of http requests by endpoint and status code.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+	}, []string{"method", "endpoint", "status"})
+
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	ContinuousAggregateLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "continuous_aggregate_lookups_total",
+		Help:      "Lookups of continuous aggregates for grouped queries by result (hit or miss).",
+	}, []string{"result"})
+
+	RowsReturned = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_rows_returned_total",
+		Help:      "Rows read from the database.",
+	})
+
+	UpstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of calls to upstream services by service and result (ok or error).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"upstream", "result"})
+
+	CacheInvalidations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_invalidations_total",
+		Help:      "Received cache invalidations by entity kind and source (kafka, webhook or local).",
+	}, []string{"kind", "source"})
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware observes the latency of each request, labeled with the route instead of the path to limit cardinality
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		endpoint := c.FullPath()
+		if endpoint == "" {
+			endpoint = "unmatched"
+		}
+		RequestDuration.WithLabelValues(c.Request.Method, endpoint, strconv.Itoa(c.Writer.Status())).Observe(time.Since(start).Seconds())
+	}
+}
+
+func ObserveCache(cache string, hit bool) {
+	CacheRequests.WithLabelValues(cache, hitOrMiss(hit)).Inc()
+}
+
+func ObserveContinuousAggregate(hit bool) {
+	ContinuousAggregateLookups.WithLabelValues(hitOrMiss(hit)).Inc()
+}
+
+// ObserveUpstream records the latency of an upstream call started at start
+func ObserveUpstream(upstream string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	UpstreamDuration.WithLabelValues(upstream, result).Observe(time.Since(start).Seconds())
+}
+
+func hitOrMiss(hit bool) string {
+	if hit {
+		return "hit"
+	}
+	return "miss"
+}
+
+// RegisterPool exports the connection stats of pool
+func RegisterPool(pool *pgx.ConnPool) error {
+	return prometheus.Register(&poolCollector{pool: pool})
+}
+
+var (
+	poolMaxConnections       = prometheus.NewDesc(namespace+"_db_pool_max_connections", "Maximum number of connections of the pool.", nil, nil)
+	poolCurrentConnections   = prometheus.NewDesc(namespace+"_db_pool_current_connections", "Open connections of the pool.", nil, nil)
+	poolAvailableConnections = prometheus.NewDesc(namespace+"_db_pool_available_connections", "Open connections of the pool which are not in use.", nil, nil)
+)
+
+type poolCollector struct {
+	pool *pgx.ConnPool
+}
+
+func (collector *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolMaxConnections
+	ch <- poolCurrentConnections
+	ch <- poolAvailableConnections
+}
+
+func (collector *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := collector.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolMaxConnections, prometheus.GaugeValue, float64(stat.MaxConnections))
+	ch <- prometheus.MustNewConstMetric(poolCurrentConnections, prometheus.GaugeValue, float64(stat.CurrentConnections))
+	ch <- prometheus.MustNewConstMetric(poolAvailableConnections, prometheus.GaugeValue, float64(stat.AvailableConnections))
+}
@@ -17,6 +17,7 @@
 package model
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -26,11 +27,20 @@ var ErrBadRequest = errors.New("bad request")
 var ErrInternalServerError = errors.New("internal server error")
 var ErrForbidden = fmt.Errorf("forbidden")
 var ErrNotFound = fmt.Errorf("not found")
+var ErrTimeout = errors.New("timeout")
+var ErrTooManyRequests = errors.New("too many requests")
+var ErrServiceUnavailable = errors.New("service unavailable")
 
 func GetStatusCode(err error) int {
 	if err == nil {
 		return http.StatusOK
 	}
+	if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) { // checked first, timeouts are often joined with ErrInternalServerError
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, ErrServiceUnavailable) {
+		return http.StatusServiceUnavailable
+	}
 	if errors.Is(err, ErrBadRequest) {
 		return http.StatusBadRequest
 	}
@@ -43,6 +53,9 @@ func GetStatusCode(err error) int {
 	if errors.Is(err, ErrForbidden) {
 		return http.StatusForbidden
 	}
+	if errors.Is(err, ErrTooManyRequests) {
+		return http.StatusTooManyRequests
+	}
 	return http.StatusInternalServerError
 }
 
@@ -58,6 +71,12 @@ func GetError(code int) error {
 		return ErrNotFound
 	case http.StatusForbidden:
 		return ErrForbidden
+	case http.StatusGatewayTimeout:
+		return ErrTimeout
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case http.StatusServiceUnavailable:
+		return ErrServiceUnavailable
 	default:
 		return ErrInternalServerError
 	}
@@ -0,0 +1,67 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// PurgeRequest deletes the data of a device, of one device service or of an export.
+// Without Start and End all data is deleted, otherwise only rows with Start <= time < End.
+type PurgeRequest struct {
+	ExportId  *string `json:"exportId,omitempty"`
+	DeviceId  *string `json:"deviceId,omitempty"`
+	ServiceId *string `json:"serviceId,omitempty"`
+	Start     *string `json:"start,omitempty"`
+	End       *string `json:"end,omitempty"`
+}
+
+func (request *PurgeRequest) Valid() bool {
+	if request.ExportId != nil {
+		if request.DeviceId != nil || request.ServiceId != nil {
+			return false
+		}
+	} else if request.DeviceId == nil || (request.ServiceId != nil && !serviceIdValid(*request.ServiceId)) {
+		return false
+	}
+	if request.Start == nil && request.End == nil {
+		return true
+	}
+	if request.Start == nil || request.End == nil {
+		return false
+	}
+	start, err := time.Parse(time.RFC3339, *request.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(time.RFC3339, *request.End)
+	if err != nil {
+		return false
+	}
+	return start.Before(end)
+}
+
+// Entire returns true if all data should be deleted
+func (request *PurgeRequest) Entire() bool {
+	return request.Start == nil && request.End == nil
+}
+
+type PurgeResponse struct {
+	Tables               []string `json:"tables"`
+	ServiceIds           []string `json:"serviceIds,omitempty"`  // services of purged device tables
+	DeletedRows          *int64   `json:"deletedRows,omitempty"` // only counted for time ranges, tables are truncated otherwise
+	ContinuousAggregates []string `json:"continuousAggregates"`  // dropped, or refreshed for time ranges
+	MaterializedViews    []string `json:"materializedViews"`     // refreshed
+}
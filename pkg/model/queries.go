@@ -18,6 +18,7 @@ package model
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,6 +38,10 @@ type QueriesRequestElement struct {
 	OrderDirection   *Direction                     `json:"orderDirection,omitempty"`
 	DeviceGroupId    *string                        `json:"deviceGroupId,omitempty"`
 	LocationId       *string                        `json:"locationId,omitempty"`
+	GroupOrigin      *string                        `json:"groupOrigin,omitempty"`
+	GroupOffset      *string                        `json:"groupOffset,omitempty"`
+	// StableOrder orders rows with the same value in the order column by all other columns, so pages can be resumed
+	StableOrder bool `json:"-"`
 }
 
 func (element *QueriesRequestElement) Valid() bool {
@@ -75,6 +80,9 @@ func (element *QueriesRequestElement) Valid() bool {
 		if column.TargetCharacteristicId != nil && column.SourceCharacteristicId == nil && element.ExportId != nil {
 			return false
 		}
+		if column.Fill != nil && *column.Fill != FillNone && element.Time == nil {
+			return false
+		}
 	}
 	if element.Filters != nil {
 		for _, filter := range *element.Filters {
@@ -86,6 +94,20 @@ func (element *QueriesRequestElement) Valid() bool {
 	if element.GroupTime != nil && !timeIntervalValid(*element.GroupTime) {
 		return false
 	}
+	if element.GroupOrigin != nil || element.GroupOffset != nil {
+		if element.GroupTime == nil || element.GapFilled() {
+			return false // time_bucket_gapfill has no origin
+		}
+		if element.GroupOrigin != nil {
+			_, err := time.Parse(time.RFC3339, *element.GroupOrigin)
+			if err != nil {
+				return false
+			}
+		}
+		if element.GroupOffset != nil && !timeIntervalValid(*element.GroupOffset) {
+			return false
+		}
+	}
 	if element.OrderDirection != nil && *element.OrderDirection != Asc && *element.OrderDirection != Desc {
 		return false
 	}
@@ -105,6 +127,52 @@ func (element *QueriesRequestElement) Valid() bool {
 	return true
 }
 
+// GapFilled returns true if at least one column fills empty time buckets
+func (element *QueriesRequestElement) GapFilled() bool {
+	for _, column := range element.Columns {
+		if column.Fill != nil && *column.Fill != FillNone {
+			return true
+		}
+	}
+	return false
+}
+
+// ColumnElement returns the element of a single column of element, filtered by filters instead of the element's filters.
+// Used to query each column of a v2 request on its own.
+func (element *QueriesRequestElement) ColumnElement(column QueriesRequestElementColumn, filters *[]QueriesRequestElementFilter) QueriesRequestElement {
+	return QueriesRequestElement{
+		ExportId:         element.ExportId,
+		DeviceId:         element.DeviceId,
+		ServiceId:        element.ServiceId,
+		Time:             element.Time,
+		Limit:            element.Limit,
+		Columns:          []QueriesRequestElementColumn{column},
+		Filters:          filters,
+		GroupTime:        element.GroupTime,
+		OrderColumnIndex: element.OrderColumnIndex,
+		OrderDirection:   element.OrderDirection,
+		GroupOrigin:      element.GroupOrigin,
+		GroupOffset:      element.GroupOffset,
+	}
+}
+
+// ExpandedElement returns the element of a single device and service selected by a device group or location element
+func (element *QueriesRequestElement) ExpandedElement(deviceId string, serviceId string, columns []QueriesRequestElementColumn) QueriesRequestElement {
+	return QueriesRequestElement{
+		DeviceId:         &deviceId,
+		ServiceId:        &serviceId,
+		Time:             element.Time,
+		Limit:            element.Limit,
+		Columns:          columns,
+		Filters:          element.Filters,
+		GroupTime:        element.GroupTime,
+		OrderColumnIndex: element.OrderColumnIndex,
+		OrderDirection:   element.OrderDirection,
+		GroupOrigin:      element.GroupOrigin,
+		GroupOffset:      element.GroupOffset,
+	}
+}
+
 type QueriesRequestElementTime struct {
 	Last        *string `json:"last,omitempty"`
 	Ahead       *string `json:"ahead,omitempty"`
@@ -174,8 +242,18 @@ type QueriesRequestElementColumn struct {
 	TargetCharacteristicId *string                          `json:"targetCharacteristicId,omitempty"`
 	ConceptId              *string                          `json:"conceptId,omitempty"`
 	Criteria               models.DeviceGroupFilterCriteria `json:"criteria,omitempty"`
+	Fill                   *string                          `json:"fill,omitempty"`
+	CounterReset           bool                             `json:"counterReset,omitempty"`
+	CounterMax             *float64                         `json:"counterMax,omitempty"`
 }
 
+const (
+	FillNone     = "none"
+	FillNull     = "null"
+	FillPrevious = "previous"
+	FillLinear   = "linear"
+)
+
 func (elementColumn *QueriesRequestElementColumn) Valid(hasTime bool) bool {
 	nameValid := columnNameValid(elementColumn.Name)
 	criteriaValid := DeviceGroupFilterCriteriaValid(elementColumn.Criteria)
@@ -188,24 +266,56 @@ func (elementColumn *QueriesRequestElementColumn) Valid(hasTime bool) bool {
 	if elementColumn.GroupType != nil && !hasTime {
 		return false
 	}
-	if elementColumn.GroupType != nil {
-		allowedTypes := []interface{}{}
-		allowedTypes = append(allowedTypes, "mean", "sum", "count", "median", "min", "max", "first", "last",
-			"difference-first", "difference-last", "difference-min", "difference-max", "difference-count", "difference-mean",
-			"difference-sum", "difference-median", "time-weighted-mean-linear", "time-weighted-mean-locf")
-		if !ElementInArray(*elementColumn.GroupType, allowedTypes) {
-			return false
-		}
+	if elementColumn.GroupType != nil && !groupTypeValid(*elementColumn.GroupType) {
+		return false
 	}
 	if elementColumn.Math != nil && !mathValid(*elementColumn.Math) {
 		return false
 	}
+	if elementColumn.CounterReset || elementColumn.CounterMax != nil {
+		if elementColumn.GroupType == nil || !strings.HasPrefix(*elementColumn.GroupType, "difference-") {
+			return false
+		}
+		if elementColumn.CounterMax != nil && *elementColumn.CounterMax <= 0 {
+			return false
+		}
+	}
+	if elementColumn.Fill != nil {
+		if elementColumn.GroupType == nil {
+			return false
+		}
+		switch *elementColumn.Fill {
+		case FillNone, FillNull, FillPrevious, FillLinear:
+		default:
+			// constant value
+			_, err := strconv.ParseFloat(*elementColumn.Fill, 64)
+			if err != nil {
+				return false
+			}
+		}
+	}
 	if elementColumn.TargetCharacteristicId != nil && elementColumn.ConceptId == nil && elementColumn.Criteria.FunctionId == "" {
 		return false
 	}
 	return true
 }
 
+// ExpandedColumn returns the column of a path selected by the criteria of a device group or location column.
+// Everything but the name and the characteristics is taken from the criteria column.
+func (elementColumn QueriesRequestElementColumn) ExpandedColumn(path string, sourceCharacteristicId string, conceptId string) QueriesRequestElementColumn {
+	return QueriesRequestElementColumn{
+		Name:                   path,
+		GroupType:              elementColumn.GroupType,
+		Math:                   elementColumn.Math,
+		SourceCharacteristicId: &sourceCharacteristicId,
+		TargetCharacteristicId: elementColumn.TargetCharacteristicId,
+		ConceptId:              &conceptId,
+		Fill:                   elementColumn.Fill,
+		CounterReset:           elementColumn.CounterReset,
+		CounterMax:             elementColumn.CounterMax,
+	}
+}
+
 type QueriesRequestElementFilter struct {
 	Column string      `json:"column,omitempty"`
 	Math   *string     `json:"math,omitempty"`
@@ -233,6 +343,41 @@ func (filter *QueriesRequestElementFilter) Valid() bool {
 	return ElementInArray(filter.Type, allowedTypes) && columnNameValid(filter.Column)
 }
 
+func groupTypeValid(groupType string) bool {
+	if groupType == "time-weighted-mean-linear" || groupType == "time-weighted-mean-locf" {
+		return true
+	}
+	base := strings.TrimPrefix(groupType, "difference-")
+	allowedTypes := []interface{}{}
+	allowedTypes = append(allowedTypes, "mean", "sum", "count", "median", "min", "max", "first", "last",
+		"stddev", "variance", "mode", "count-distinct", "skewness", "kurtosis")
+	if ElementInArray(base, allowedTypes) {
+		return true
+	}
+	_, _, ok := ParsePercentile(base)
+	return ok
+}
+
+// ParsePercentile parses group types like percentile-95 or percentile-approx-99.9.
+// Returns the percentile as fraction and whether an approximation was requested.
+func ParsePercentile(groupType string) (fraction float64, approx bool, ok bool) {
+	percentile, found := strings.CutPrefix(groupType, "percentile-")
+	if !found {
+		return 0, false, false
+	}
+	percentile, approx = strings.CutPrefix(percentile, "approx-")
+	if len(percentile) != len(percentileMatcher.FindString(percentile)) {
+		return 0, false, false
+	}
+	p, err := strconv.ParseFloat(percentile, 64)
+	if err != nil || p <= 0 || p >= 100 {
+		return 0, false, false
+	}
+	return p / 100, approx, true
+}
+
+var percentileMatcher = regexp.MustCompile("\\d+(\\.\\d+)?")
+
 var mathMatcher = regexp.MustCompile("([+\\-*/])\\d+(([.,])\\d+)?")
 
 func mathValid(math string) bool {
@@ -264,6 +409,9 @@ type Format string
 const (
 	PerQuery Format = "per_query"
 	Table    Format = "table"
+	Arrow    Format = "arrow"
+	Csv      Format = "csv"
+	Parquet  Format = "parquet"
 )
 
 type Direction string
@@ -275,8 +423,11 @@ const (
 
 type PreparedQueriesRequestElement struct {
 	QueriesRequestElement
-	Token      string `json:"token,omitempty"`
-	TimeFormat string `json:"timeFormat,omitempty"`
+	Token       string `json:"token,omitempty"`
+	TimeFormat  string `json:"timeFormat,omitempty"`
+	UserId      string `json:"userId,omitempty"`
+	OwnerUserId string `json:"ownerUserId,omitempty"`
+	Format      Format `json:"format,omitempty"`
 }
 
 func DeviceGroupFilterCriteriaValid(criteria models.DeviceGroupFilterCriteria) bool {
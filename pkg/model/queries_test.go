@@ -18,6 +18,7 @@ package model
 
 import (
 	"fmt"
+	"github.com/SENERGY-Platform/models/go/models"
 	"testing"
 )
 
@@ -97,3 +98,179 @@ func TestTimeIntervalValidators(t *testing.T) {
 	}
 
 }
+
+func TestGroupTypeValidators(t *testing.T) {
+	tt := []struct {
+		GroupType string
+		Expected  bool
+	}{
+		{GroupType: "mean", Expected: true},
+		{GroupType: "difference-last", Expected: true},
+		{GroupType: "time-weighted-mean-linear", Expected: true},
+		{GroupType: "difference-time-weighted-mean-linear", Expected: false},
+		{GroupType: "stddev", Expected: true},
+		{GroupType: "variance", Expected: true},
+		{GroupType: "mode", Expected: true},
+		{GroupType: "count-distinct", Expected: true},
+		{GroupType: "difference-count-distinct", Expected: true},
+		{GroupType: "skewness", Expected: true},
+		{GroupType: "percentile-95", Expected: true},
+		{GroupType: "percentile-99.9", Expected: true},
+		{GroupType: "percentile-approx-99", Expected: true},
+		{GroupType: "difference-percentile-50", Expected: true},
+		{GroupType: "percentile-0", Expected: false},
+		{GroupType: "percentile-100", Expected: false},
+		{GroupType: "percentile-1e1", Expected: false},
+		{GroupType: "percentile-", Expected: false},
+		{GroupType: "percentile-95)", Expected: false},
+		{GroupType: "unknown", Expected: false},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("Test Group Type Validator: %s", tc.GroupType), func(t *testing.T) {
+			validationResult := groupTypeValid(tc.GroupType)
+			if validationResult != tc.Expected {
+				t.Errorf("Want: %t - Got: %t", tc.Expected, validationResult)
+			}
+		})
+	}
+}
+
+func TestWriteRequestValid(t *testing.T) {
+	deviceId := "urn:infai:ses:device:ade1fba6-fa5f-4704-9997-81dc168f62f4"
+	serviceId := "urn:infai:ses:service:97805820-ca0a-46c5-9dcf-16c2e386b050"
+	exportId := "e3a9d39f-d833-45df-81c0-e479d17c2e06"
+	tt := []struct {
+		Name     string
+		Request  WriteRequest
+		Expected bool
+	}{
+		{
+			Name:     "device",
+			Request:  WriteRequest{DeviceId: &deviceId, ServiceId: &serviceId, Columns: []string{"value"}, Rows: [][]interface{}{{"2021-06-20T00:00:00Z", 1.0}}},
+			Expected: true,
+		},
+		{
+			Name:     "export",
+			Request:  WriteRequest{ExportId: &exportId, Columns: []string{"a", "b"}, Rows: [][]interface{}{{"2021-06-20T00:00:00+02:00", "x", nil}}},
+			Expected: true,
+		},
+		{
+			Name:     "export and device",
+			Request:  WriteRequest{ExportId: &exportId, DeviceId: &deviceId, ServiceId: &serviceId, Columns: []string{"value"}, Rows: [][]interface{}{{"2021-06-20T00:00:00Z", 1.0}}},
+			Expected: false,
+		},
+		{
+			Name:     "missing service",
+			Request:  WriteRequest{DeviceId: &deviceId, Columns: []string{"value"}, Rows: [][]interface{}{{"2021-06-20T00:00:00Z", 1.0}}},
+			Expected: false,
+		},
+		{
+			Name:     "time column",
+			Request:  WriteRequest{ExportId: &exportId, Columns: []string{"time"}, Rows: [][]interface{}{{"2021-06-20T00:00:00Z", "2021-06-20T00:00:00Z"}}},
+			Expected: false,
+		},
+		{
+			Name:     "duplicate column",
+			Request:  WriteRequest{ExportId: &exportId, Columns: []string{"a", "a"}, Rows: [][]interface{}{{"2021-06-20T00:00:00Z", 1.0, 2.0}}},
+			Expected: false,
+		},
+		{
+			Name:     "invalid column name",
+			Request:  WriteRequest{ExportId: &exportId, Columns: []string{"a\"; DROP TABLE x; --"}, Rows: [][]interface{}{{"2021-06-20T00:00:00Z", 1.0}}},
+			Expected: false,
+		},
+		{
+			Name:     "row length",
+			Request:  WriteRequest{ExportId: &exportId, Columns: []string{"value"}, Rows: [][]interface{}{{"2021-06-20T00:00:00Z"}}},
+			Expected: false,
+		},
+		{
+			Name:     "invalid time",
+			Request:  WriteRequest{ExportId: &exportId, Columns: []string{"value"}, Rows: [][]interface{}{{"yesterday", 1.0}}},
+			Expected: false,
+		},
+		{
+			Name:     "object value",
+			Request:  WriteRequest{ExportId: &exportId, Columns: []string{"value"}, Rows: [][]interface{}{{"2021-06-20T00:00:00Z", map[string]interface{}{}}}},
+			Expected: false,
+		},
+		{
+			Name:     "no rows",
+			Request:  WriteRequest{ExportId: &exportId, Columns: []string{"value"}},
+			Expected: false,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if tc.Request.Valid() != tc.Expected {
+				t.Error("Expected", tc.Expected)
+			}
+		})
+	}
+
+	deleteRequest := DeleteRowsRequest{ExportId: &exportId, Start: "2021-06-20T00:00:00Z", End: "2021-06-21T00:00:00Z"}
+	if !deleteRequest.Valid() {
+		t.Error("Expected valid delete request")
+	}
+	deleteRequest.Start, deleteRequest.End = deleteRequest.End, deleteRequest.Start
+	if deleteRequest.Valid() {
+		t.Error("Expected invalid delete request with end before start")
+	}
+}
+
+func TestPurgeRequestValid(t *testing.T) {
+	deviceId := "urn:infai:ses:device:ade1fba6-fa5f-4704-9997-81dc168f62f4"
+	serviceId := "urn:infai:ses:service:97805820-ca0a-46c5-9dcf-16c2e386b050"
+	invalidServiceId := "97805820-ca0a-46c5-9dcf-16c2e386b050"
+	exportId := "e3a9d39f-d833-45df-81c0-e479d17c2e06"
+	start := "2021-06-20T00:00:00Z"
+	end := "2021-06-21T00:00:00Z"
+	tt := []struct {
+		Name     string
+		Request  PurgeRequest
+		Expected bool
+	}{
+		{Name: "device", Request: PurgeRequest{DeviceId: &deviceId}, Expected: true},
+		{Name: "device service", Request: PurgeRequest{DeviceId: &deviceId, ServiceId: &serviceId}, Expected: true},
+		{Name: "export range", Request: PurgeRequest{ExportId: &exportId, Start: &start, End: &end}, Expected: true},
+		{Name: "nothing", Request: PurgeRequest{}, Expected: false},
+		{Name: "service only", Request: PurgeRequest{ServiceId: &serviceId}, Expected: false},
+		{Name: "invalid service", Request: PurgeRequest{DeviceId: &deviceId, ServiceId: &invalidServiceId}, Expected: false},
+		{Name: "export and device", Request: PurgeRequest{ExportId: &exportId, DeviceId: &deviceId}, Expected: false},
+		{Name: "start only", Request: PurgeRequest{DeviceId: &deviceId, Start: &start}, Expected: false},
+		{Name: "end before start", Request: PurgeRequest{DeviceId: &deviceId, Start: &end, End: &start}, Expected: false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if tc.Request.Valid() != tc.Expected {
+				t.Error("Expected", tc.Expected)
+			}
+		})
+	}
+}
+
+func TestExpandedColumn(t *testing.T) {
+	differenceLast := "difference-last"
+	fill := FillPrevious
+	counterMax := 65535.0
+	column := QueriesRequestElementColumn{
+		GroupType:    &differenceLast,
+		Fill:         &fill,
+		CounterReset: true,
+		CounterMax:   &counterMax,
+		Criteria:     models.DeviceGroupFilterCriteria{FunctionId: "function", AspectId: "aspect"},
+	}
+	expanded := column.ExpandedColumn("energy.total", "characteristic", "concept")
+	if expanded.Name != "energy.total" || *expanded.SourceCharacteristicId != "characteristic" || *expanded.ConceptId != "concept" {
+		t.Error("unexpected path", expanded)
+	}
+	if expanded.GroupType != column.GroupType || expanded.Fill != column.Fill {
+		t.Error("expected grouping and fill of criteria column", expanded)
+	}
+	if !expanded.CounterReset || expanded.CounterMax != column.CounterMax {
+		t.Error("expected counter reset handling of criteria column", expanded)
+	}
+	if expanded.Criteria.FunctionId != "" {
+		t.Error("expected no criteria", expanded)
+	}
+}
@@ -25,3 +25,24 @@ type QueriesV2ResponseElement struct {
 	ExportId     *string           `json:"exportId,omitempty"`
 	ColumnNames  []string          `json:"columnNames,omitempty"`
 }
+
+type QueriesV2DryRunResponseElement struct {
+	RequestIndex        int           `json:"requestIndex"`
+	DeviceId            *string       `json:"deviceId,omitempty"`
+	ServiceId           *string       `json:"serviceId,omitempty"`
+	ExportId            *string       `json:"exportId,omitempty"`
+	ColumnNames         []string      `json:"columnNames,omitempty"`
+	Sql                 string        `json:"sql"`
+	Args                []interface{} `json:"args"`
+	Table               string        `json:"table"`
+	ContinuousAggregate bool          `json:"continuousAggregate"`
+	Plan                QueryPlan     `json:"plan"`
+}
+
+// QueryPlan holds the estimates of the top plan node reported by EXPLAIN
+type QueryPlan struct {
+	NodeType    string  `json:"nodeType"`
+	StartupCost float64 `json:"startupCost"`
+	TotalCost   float64 `json:"totalCost"`
+	Rows        int64   `json:"rows"`
+}
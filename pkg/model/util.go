@@ -17,6 +17,7 @@
 package model
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"sort"
@@ -33,6 +34,30 @@ func ElementInArray(element interface{}, array []interface{}) bool {
 	return false
 }
 
+// CallWithContext returns the result of f or the error of ctx, whichever comes first.
+// Meant for upstream clients without context support: f is not interrupted, but the caller does not have to wait for it.
+func CallWithContext[T any](ctx context.Context, f func() (T, error)) (result T, err error) {
+	err = ctx.Err()
+	if err != nil {
+		return result, err
+	}
+	type response struct {
+		result T
+		err    error
+	}
+	done := make(chan response, 1) // buffered, f may finish after the caller is gone
+	go func() {
+		result, err := f()
+		done <- response{result: result, err: err}
+	}()
+	select {
+	case resp := <-done:
+		return resp.result, resp.err
+	case <-ctx.Done():
+		return result, ctx.Err()
+	}
+}
+
 /*
 Removes an element form an array. If the array was ordered before, it will loose that order.
 */
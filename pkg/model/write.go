@@ -0,0 +1,104 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WriteRequest writes rows to the table of a device service or an export.
+// Each row starts with the time as RFC3339 string, followed by one value per column.
+type WriteRequest struct {
+	ExportId  *string         `json:"exportId,omitempty"`
+	DeviceId  *string         `json:"deviceId,omitempty"`
+	ServiceId *string         `json:"serviceId,omitempty"`
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+}
+
+func (request *WriteRequest) Valid() bool {
+	if !writeTargetValid(request.ExportId, request.DeviceId, request.ServiceId) {
+		return false
+	}
+	if len(request.Columns) == 0 || len(request.Rows) == 0 {
+		return false
+	}
+	known := map[string]bool{}
+	for _, column := range request.Columns {
+		if !columnNameValid(column) || column == "time" || known[column] {
+			return false
+		}
+		known[column] = true
+	}
+	for _, row := range request.Rows {
+		if len(row) != len(request.Columns)+1 {
+			return false
+		}
+		t, ok := row[0].(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return false
+		}
+		for _, value := range row[1:] {
+			switch value.(type) {
+			case nil, string, bool, float64, json.Number:
+			default:
+				return false // objects and arrays have no column
+			}
+		}
+	}
+	return true
+}
+
+// DeleteRowsRequest deletes all rows of a device service or an export with Start <= time < End
+type DeleteRowsRequest struct {
+	ExportId  *string `json:"exportId,omitempty"`
+	DeviceId  *string `json:"deviceId,omitempty"`
+	ServiceId *string `json:"serviceId,omitempty"`
+	Start     string  `json:"start"`
+	End       string  `json:"end"`
+}
+
+func (request *DeleteRowsRequest) Valid() bool {
+	if !writeTargetValid(request.ExportId, request.DeviceId, request.ServiceId) {
+		return false
+	}
+	start, err := time.Parse(time.RFC3339, request.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(time.RFC3339, request.End)
+	if err != nil {
+		return false
+	}
+	return start.Before(end)
+}
+
+type WriteResponse struct {
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+func writeTargetValid(exportId *string, deviceId *string, serviceId *string) bool {
+	if exportId != nil {
+		return deviceId == nil && serviceId == nil
+	}
+	return deviceId != nil && serviceId != nil && serviceIdValid(*serviceId)
+}
@@ -0,0 +1,126 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package timescale
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var intervalPartsRegex = regexp.MustCompile(`^(\d+)\s*(ms|s|months|mon|m|h|day|d|w|y)$`)
+
+// parseInterval splits intervals like 15m or 1months into number and unit
+func parseInterval(interval string) (n int, unit string, err error) {
+	parts := intervalPartsRegex.FindStringSubmatch(strings.TrimSpace(interval))
+	if parts == nil {
+		return 0, "", fmt.Errorf("could not parse interval %v", interval)
+	}
+	n, err = strconv.Atoi(parts[1])
+	return n, parts[2], err
+}
+
+func addInterval(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "ms":
+		return t.Add(time.Duration(n) * time.Millisecond)
+	case "s":
+		return t.Add(time.Duration(n) * time.Second)
+	case "m":
+		return t.Add(time.Duration(n) * time.Minute)
+	case "h":
+		return t.Add(time.Duration(n) * time.Hour)
+	case "day", "d":
+		return t.AddDate(0, 0, n)
+	case "w":
+		return t.AddDate(0, 0, 7*n)
+	case "months", "mon":
+		return t.AddDate(0, n, 0)
+	case "y":
+		return t.AddDate(n, 0, 0)
+	}
+	return t
+}
+
+// ApproxIntervalDuration returns the duration of intervals like 15m or 1months. Months and years have no fixed length and are approximated.
+func ApproxIntervalDuration(interval string) (time.Duration, error) {
+	n, unit, err := parseInterval(interval)
+	if err != nil {
+		return 0, err
+	}
+	return approxIntervalDuration(n, unit), nil
+}
+
+func approxIntervalDuration(n int, unit string) time.Duration {
+	switch unit {
+	case "day", "d":
+		return time.Duration(n) * 24 * time.Hour
+	case "w":
+		return time.Duration(n) * 7 * 24 * time.Hour
+	case "months", "mon":
+		return time.Duration(n) * 730 * time.Hour
+	case "y":
+		return time.Duration(n) * 8766 * time.Hour
+	}
+	return addInterval(time.Time{}, n, unit).Sub(time.Time{})
+}
+
+// alignToBucket returns the start of the bucket containing t, calculated like time_bucket with origin and offset.
+// Calendar units are added in the timezone, so days and months keep their boundaries across daylight saving time.
+func alignToBucket(t time.Time, groupTime string, origin *string, offset *string, timezone string) (time.Time, error) {
+	n, unit, err := parseInterval(groupTime)
+	if err != nil {
+		return t, err
+	}
+	if n <= 0 {
+		return t, fmt.Errorf("invalid GroupTime %v", groupTime)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return t, err
+	}
+	// defaults of time_bucket: weeks start on monday, months and years on the first of january
+	o := time.Date(2000, 1, 3, 0, 0, 0, 0, location)
+	if unit == "months" || unit == "mon" || unit == "y" {
+		o = time.Date(2000, 1, 1, 0, 0, 0, 0, location)
+	}
+	if origin != nil {
+		o, err = time.Parse(time.RFC3339, *origin)
+		if err != nil {
+			return t, err
+		}
+		o = o.In(location)
+	}
+	if offset != nil {
+		offsetN, offsetUnit, err := parseInterval(*offset)
+		if err != nil {
+			return t, err
+		}
+		o = addInterval(o, offsetN, offsetUnit)
+	}
+
+	k := int(t.Sub(o) / approxIntervalDuration(n, unit))
+	for addInterval(o, k*n, unit).After(t) {
+		k--
+	}
+	for !addInterval(o, (k+1)*n, unit).After(t) {
+		k++
+	}
+	return addInterval(o, k*n, unit), nil
+}
@@ -0,0 +1,56 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package timescale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlignToBucket(t *testing.T) {
+	origin15th := "2021-01-15T00:00:00+01:00"
+	offset6h := "6h"
+	tt := []struct {
+		Name      string
+		Time      string
+		GroupTime string
+		Origin    *string
+		Offset    *string
+		Expected  string
+	}{
+		{Name: "week starts on monday", Time: "2021-06-20T12:00:00Z", GroupTime: "1w", Expected: "2021-06-14T00:00:00+02:00"},
+		{Name: "day with offset", Time: "2021-06-20T03:00:00Z", GroupTime: "1d", Offset: &offset6h, Expected: "2021-06-19T06:00:00+02:00"},
+		{Name: "month with origin", Time: "2021-06-10T00:00:00Z", GroupTime: "1months", Origin: &origin15th, Expected: "2021-05-15T00:00:00+02:00"},
+		{Name: "month with origin after", Time: "2020-12-20T00:00:00Z", GroupTime: "1mon", Origin: &origin15th, Expected: "2020-12-15T00:00:00+01:00"},
+		{Name: "minutes", Time: "2021-06-20T12:07:00Z", GroupTime: "15m", Expected: "2021-06-20T14:00:00+02:00"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ts, err := time.Parse(time.RFC3339, tc.Time)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := alignToBucket(ts, tc.GroupTime, tc.Origin, tc.Offset, "Europe/Berlin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual.Format(time.RFC3339) != tc.Expected {
+				t.Error("Expected/Actual\n", tc.Expected, "\n", actual.Format(time.RFC3339))
+			}
+		})
+	}
+}
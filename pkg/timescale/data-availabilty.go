@@ -17,10 +17,12 @@
 package timescale
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/SENERGY-Platform/models/go/models"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/jackc/pgx"
 	"regexp"
 	"sync"
 )
@@ -31,7 +33,7 @@ var serviceRegex = regexp.MustCompile("device:.*_service:(.{22})")
 var intervalRegex = regexp.MustCompile("time_bucket\\('(.*)'")
 var typeRegex = regexp.MustCompile("(\\S*)\\(\"")
 
-func (wrapper *Wrapper) GetDataAvailability(deviceId string) (res []model.DataAvailabilityResponseElement, err error) {
+func (wrapper *Wrapper) GetDataAvailability(ctx context.Context, deviceId string) (res []model.DataAvailabilityResponseElement, err error) {
 	shortDeviceId, err := shortenId(deviceId)
 	if err != nil {
 		return nil, err
@@ -40,56 +42,43 @@ func (wrapper *Wrapper) GetDataAvailability(deviceId string) (res []model.DataAv
 	if err != nil {
 		return nil, err
 	}
-	rows, err := wrapper.pool.Query("SELECT view_name, view_definition FROM timescaledb_information.continuous_aggregates WHERE hypertable_name LIKE '" + tablePrefix + "%';")
+	views := map[string]*string{} // view or table name to view definition, nil for tables
+	err = wrapper.queryRows(ctx, func(rows *pgx.Rows) error {
+		var viewName, viewDescription string
+		err := rows.Scan(&viewName, &viewDescription)
+		views[viewName] = &viewDescription
+		return err
+	}, "SELECT view_name, view_definition FROM timescaledb_information.continuous_aggregates WHERE hypertable_name LIKE '"+tablePrefix+"%';")
+	if err != nil {
+		return nil, err
+	}
+	err = wrapper.queryRows(ctx, func(rows *pgx.Rows) error {
+		var tableName string
+		err := rows.Scan(&tableName)
+		views[tableName] = nil
+		return err
+	}, "SELECT table_name FROM information_schema.tables WHERE table_name ~ '"+tablePrefix+"service:.{22}$';")
 	if err != nil {
 		return nil, err
 	}
+
+	// rows are closed before parsing, so their connections and scheduler slots are free for the queries of parseDataAvailability
 	mtx := sync.Mutex{}
 	wg := sync.WaitGroup{}
 	var anyErr error
 	res = []model.DataAvailabilityResponseElement{}
-	for rows.Next() {
-		var viewName, viewDescription string
-		err = rows.Scan(&viewName, &viewDescription)
-		if err != nil {
-			return nil, err
-		}
+	for viewTableName, viewDescription := range views {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-
-			elem, err := wrapper.parseDataAvailability(viewName, &viewDescription)
-			if err != nil {
-				anyErr = err
-				return
-			}
+			elem, err := wrapper.parseDataAvailability(ctx, viewTableName, viewDescription)
 			mtx.Lock()
-			res = append(res, *elem)
-			mtx.Unlock()
-		}()
-	}
-
-	rows, err = wrapper.pool.Query("SELECT table_name FROM information_schema.tables WHERE table_name ~ '" + tablePrefix + "service:.{22}$';")
-	if err != nil {
-		return nil, err
-	}
-	for rows.Next() {
-		var tableName string
-		err = rows.Scan(&tableName)
-		if err != nil {
-			return nil, err
-		}
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			elem, err := wrapper.parseDataAvailability(tableName, nil)
+			defer mtx.Unlock()
 			if err != nil {
 				anyErr = err
 				return
 			}
-			mtx.Lock()
 			res = append(res, *elem)
-			mtx.Unlock()
 		}()
 	}
 	wg.Wait()
@@ -99,7 +88,7 @@ func (wrapper *Wrapper) GetDataAvailability(deviceId string) (res []model.DataAv
 	return
 }
 
-func (wrapper *Wrapper) parseDataAvailability(viewTableName string, viewDescription *string) (*model.DataAvailabilityResponseElement, error) {
+func (wrapper *Wrapper) parseDataAvailability(ctx context.Context, viewTableName string, viewDescription *string) (*model.DataAvailabilityResponseElement, error) {
 	serviceMatches := serviceRegex.FindStringSubmatch(viewTableName)
 	if len(serviceMatches) < 2 {
 		return nil, errors.New("unexpected service matches from view name")
@@ -127,22 +116,16 @@ func (wrapper *Wrapper) parseDataAvailability(viewTableName string, viewDescript
 		GroupTime: groupTime,
 	}
 
-	subRows, err := wrapper.pool.Query(fmt.Sprintf("(SELECT time from \"%s\" ORDER BY time ASC LIMIT 1) UNION ALL (SELECT time from \"%s\" ORDER BY time DESC LIMIT 1);", viewTableName, viewTableName))
-	if err != nil {
-		return nil, err
-	}
-
 	i := 0
-	for subRows.Next() {
+	err = wrapper.queryRows(ctx, func(rows *pgx.Rows) error {
 		if i == 0 {
 			i++
-			err = subRows.Scan(&elem.From)
-		} else {
-			err = subRows.Scan(&elem.To)
-		}
-		if err != nil {
-			return nil, err
+			return rows.Scan(&elem.From)
 		}
+		return rows.Scan(&elem.To)
+	}, fmt.Sprintf("(SELECT time from \"%s\" ORDER BY time ASC LIMIT 1) UNION ALL (SELECT time from \"%s\" ORDER BY time DESC LIMIT 1);", viewTableName, viewTableName))
+	if err != nil {
+		return nil, err
 	}
 	return &elem, nil
 }
@@ -17,29 +17,63 @@
 package timescale
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"math"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
+	"github.com/jackc/pgx"
 	"github.com/jackc/pgx/pgtype"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func (wrapper *Wrapper) ExecuteQueries(queries []string) (res [][][]interface{}, err error) {
+var ErrRowLimitExceeded = errors.New("row limit exceeded")
+
+type rowLimitKey struct{}
+
+// WithRowLimit limits the number of rows all queries executed with the returned context may read together.
+// Queries fail with ErrRowLimitExceeded as soon as the limit is exceeded.
+func WithRowLimit(ctx context.Context, rows int64) context.Context {
+	remaining := &atomic.Int64{}
+	remaining.Store(rows)
+	return context.WithValue(ctx, rowLimitKey{}, remaining)
+}
+
+func (wrapper *Wrapper) ExecuteQueries(ctx context.Context, queries []Query) (res [][][]interface{}, err error) {
+	res, _, err = wrapper.ExecuteQueriesWithFields(ctx, queries)
+	return
+}
+
+// ExecuteQueriesWithFields works like ExecuteQueries, but additionally returns the field descriptions of each query.
+// Queries are started in parallel, the scheduler decides how many of them run at once.
+func (wrapper *Wrapper) ExecuteQueriesWithFields(ctx context.Context, queries []Query) (res [][][]interface{}, fields [][]Field, err error) {
 	res = make([][][]interface{}, len(queries))
+	fields = make([][]Field, len(queries))
 	wg := sync.WaitGroup{} // handle multiple queries in parallel
+	mux := sync.Mutex{}
 	for i, query := range queries {
 		wg.Add(1)
 		i := i         // make thread safe
 		query := query // make thread safe
 		go func() {
 			if wrapper.config.Debug {
-				log.Logger.Debug("Query", "index", i, "query", query)
+				log.Logger.Debug("Query", "index", i, "query", query.Sql, "args", query.Args)
 			}
-			resS, errS := wrapper.ExecuteQuery(query)
+			resS, fieldsS, errS := wrapper.executeQuery(ctx, query.Sql, query.Args...)
 			if errS != nil { // Prevents overwriting with nil
+				mux.Lock()
 				err = errS
+				mux.Unlock()
 			} else {
 				res[i] = resS
+				fields[i] = fieldsS
 			}
 			wg.Done()
 		}()
@@ -48,32 +82,188 @@ func (wrapper *Wrapper) ExecuteQueries(queries []string) (res [][][]interface{},
 	return
 }
 
-func (wrapper *Wrapper) ExecuteQuery(query string) (res [][]interface{}, err error) {
-	rows, err := wrapper.pool.Query(query)
+func (wrapper *Wrapper) ExecuteQuery(ctx context.Context, query string, args ...interface{}) (res [][]interface{}, err error) {
+	res, _, err = wrapper.executeQuery(ctx, query, args...)
+	return
+}
+
+func (wrapper *Wrapper) executeQuery(ctx context.Context, query string, args ...interface{}) (res [][]interface{}, fields []Field, err error) {
+	res = [][]interface{}{}
+	fields, err = wrapper.StreamQuery(ctx, query, args, func(values []interface{}) error {
+		res = append(res, values)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(res) == 0 { // no results --> append nil for each requested field
+		res = append(res, make([]interface{}, len(fields)))
+	}
+	return res, fields, nil
+}
+
+// StreamQuery passes each row to handle as soon as it is read from the cursor, so results don't have to fit in memory.
+// Returns the field descriptions of the result. The query is cancelled when ctx is done.
+// The scheduler slot is released with the first row, the connection is held until all rows were handled.
+// Callers writing rows to clients have to bound their writes with a deadline.
+func (wrapper *Wrapper) StreamQuery(ctx context.Context, query string, args []interface{}, handle func(values []interface{}) error) (fields []Field, err error) {
+	ctx, span := tracing.Start(ctx, "Wrapper.StreamQuery", attribute.String("db.system", "postgresql"), attribute.String("db.query.text", query))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	if wrapper.pool == nil {
+		return nil, ErrNotConnected
+	}
+	beforeAcquire := time.Now()
+	release, err := wrapper.scheduler.acquire(ctx)
 	if err != nil {
 		return nil, err
 	}
-	res = [][]interface{}{}
+	defer release()
+	span.SetAttributes(attribute.Int64("db.scheduler.wait_ms", time.Since(beforeAcquire).Milliseconds()))
+	rows, err := wrapper.pool.QueryEx(ctx, query, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	remaining, _ := ctx.Value(rowLimitKey{}).(*atomic.Int64)
+	rowCount := 0
+	defer func() {
+		metrics.RowsReturned.Add(float64(rowCount))
+		span.SetAttributes(attribute.Int("db.response.returned_rows", rowCount))
+	}()
 	for rows.Next() {
+		release() // the query is executing, reading its rows depends on handle and must not keep other users waiting
+		rowCount++
+		if remaining != nil && remaining.Add(-1) < 0 {
+			return nil, ErrRowLimitExceeded
+		}
 		values, err := rows.Values()
 		if err != nil {
-			rows.Close()
 			return nil, err
 		}
 		for i, v := range values {
-			numeric, ok := v.(*pgtype.Numeric)
-			if ok {
-				if numeric.Status == pgtype.Present {
-					values[i] = int64(float64(numeric.Int.Int64()) * math.Pow10(int(numeric.Exp)))
-				} else {
-					values[i] = nil
-				}
-			}
+			values[i] = rowValue(v)
+		}
+		err = handle(values)
+		if err != nil {
+			return nil, err
 		}
-		res = append(res, values)
 	}
-	if len(res) == 0 { // no results --> append nil for each requested field
-		res = append(res, make([]interface{}, len(rows.FieldDescriptions())))
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	for _, description := range rows.FieldDescriptions() {
+		fields = append(fields, Field{Name: description.Name, DataTypeName: description.DataTypeName})
+	}
+	return fields, nil
+}
+
+// queryRows passes each row of the query to scan and closes the rows before it returns
+func (wrapper *Wrapper) queryRows(ctx context.Context, scan func(rows *pgx.Rows) error, query string, args ...interface{}) error {
+	release, err := wrapper.scheduler.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	rows, err := wrapper.pool.QueryEx(ctx, query, nil, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err = scan(rows)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// exec runs a single statement outside of a transaction, procedures like refresh_continuous_aggregate refuse to run inside one
+func (wrapper *Wrapper) exec(ctx context.Context, query string, args ...interface{}) (rowsAffected int64, err error) {
+	release, err := wrapper.scheduler.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	if wrapper.config.Debug {
+		log.Logger.Debug("Exec", "query", query, "args", args)
+	}
+	tag, err := wrapper.pool.ExecEx(ctx, query, nil, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ExplainQueries asks TimescaleDB for the plan of each query without executing it
+func (wrapper *Wrapper) ExplainQueries(ctx context.Context, queries []Query) (plans []model.QueryPlan, err error) {
+	plans = make([]model.QueryPlan, len(queries))
+	wg := sync.WaitGroup{}
+	mux := sync.Mutex{}
+	for i, query := range queries {
+		wg.Add(1)
+		i := i
+		query := query
+		go func() {
+			defer wg.Done()
+			plan, errS := wrapper.ExplainQuery(ctx, query.Sql, query.Args...)
+			if errS != nil {
+				mux.Lock()
+				err = errS
+				mux.Unlock()
+				return
+			}
+			plans[i] = plan
+		}()
+	}
+	wg.Wait()
+	return
+}
+
+func (wrapper *Wrapper) ExplainQuery(ctx context.Context, query string, args ...interface{}) (plan model.QueryPlan, err error) {
+	var raw string
+	release, err := wrapper.scheduler.acquire(ctx)
+	if err != nil {
+		return plan, err
+	}
+	defer release()
+	err = wrapper.pool.QueryRowEx(ctx, "EXPLAIN (FORMAT JSON) "+query, nil, args...).Scan(&raw)
+	if err != nil {
+		return plan, err
+	}
+	var explained []struct {
+		Plan struct {
+			NodeType    string  `json:"Node Type"`
+			StartupCost float64 `json:"Startup Cost"`
+			TotalCost   float64 `json:"Total Cost"`
+			PlanRows    int64   `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	err = json.Unmarshal([]byte(raw), &explained)
+	if err != nil {
+		return plan, err
+	}
+	if len(explained) == 0 {
+		return plan, errors.New("empty query plan")
+	}
+	return model.QueryPlan{
+		NodeType:    explained[0].Plan.NodeType,
+		StartupCost: explained[0].Plan.StartupCost,
+		TotalCost:   explained[0].Plan.TotalCost,
+		Rows:        explained[0].Plan.PlanRows,
+	}, nil
+}
+
+// rowValue converts a value read from the database for responses. Numerics are returned as integers, their fraction is truncated.
+func rowValue(value interface{}) interface{} {
+	numeric, ok := value.(*pgtype.Numeric)
+	if !ok {
+		return value
+	}
+	if numeric.Status != pgtype.Present {
+		return nil
 	}
-	return res, nil
+	return int64(float64(numeric.Int.Int64()) * math.Pow10(int(numeric.Exp)))
 }
@@ -0,0 +1,74 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package timescale
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/jackc/pgx/pgtype"
+)
+
+// TestRowValueLikeCache compares the values of columns with math served from the cache with the values read from the database.
+// A cached value doesn't know the type of its column, so it has to match integer and double precision columns or must not be cachable.
+func TestRowValueLikeCache(t *testing.T) {
+	numeric := func(i int64, exp int32) *pgtype.Numeric {
+		return &pgtype.Numeric{Int: big.NewInt(i), Exp: exp, Status: pgtype.Present}
+	}
+	for _, tc := range []struct {
+		value   float64
+		math    string
+		integer interface{} // returned by postgres for a bigint column, nil if value is no integer
+		float   float64     // returned by postgres for a double precision column
+	}{
+		{value: 7, math: "+2", integer: int64(9), float: 9},
+		{value: 7, math: "-2", integer: int64(5), float: 5},
+		{value: 7, math: "*2", integer: int64(14), float: 14},
+		{value: 7, math: "*0.5", integer: numeric(35, -1), float: 3.5},
+		{value: 4, math: "*0.5", integer: numeric(20, -1), float: 2},
+		{value: -7, math: "*0.5", integer: numeric(-35, -1), float: -3.5},
+		{value: 7, math: "+0.5", integer: numeric(75, -1), float: 7.5},
+		{value: 7, math: "/2", integer: int64(3), float: 3.5},
+		{value: 8, math: "/2", integer: int64(4), float: 4},
+		{value: -7, math: "/2", integer: int64(-3), float: -3.5},
+		{value: 7, math: "/0.5", integer: numeric(140, -1), float: 14},
+		{value: 7.5, math: "/2", integer: nil, float: 3.75},
+	} {
+		math := tc.math
+		columns := []model.QueriesRequestElementColumn{{Name: "value", Math: &math}}
+		row, err := cache.Entry{Value: map[string]interface{}{"value": tc.value}}.Row(columns)
+		var integer interface{}
+		if tc.integer != nil {
+			integer = rowValue(tc.integer)
+		}
+		if errors.Is(err, cache.NotCachableError) {
+			if integer == nil || float64(integer.(int64)) == tc.float {
+				t.Error("expected cachable", tc.value, tc.math)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row[1] != tc.float || (integer != nil && row[1] != float64(integer.(int64))) {
+			t.Error("cache and database differ", tc.value, tc.math, row[1], integer, tc.float)
+		}
+	}
+}
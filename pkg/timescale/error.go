@@ -17,6 +17,7 @@
 package timescale
 
 import (
+	"context"
 	"errors"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx"
@@ -27,6 +28,15 @@ func GetHTTPErrorCode(err error) int {
 	if err == nil {
 		return http.StatusOK
 	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, ErrQueueTimeout) || errors.Is(err, pgx.ErrAcquireTimeout) {
+		return http.StatusServiceUnavailable
+	}
+	if errors.Is(err, ErrRowLimitExceeded) {
+		return http.StatusTooManyRequests
+	}
 	var pgErr pgx.PgError
 	if errors.As(err, &pgErr) {
 		switch {
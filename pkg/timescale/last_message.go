@@ -17,6 +17,7 @@
 package timescale
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -28,34 +29,16 @@ import (
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
 )
 
-func (wrapper *Wrapper) GetLastMessage(deviceId string, serviceId string, service models.Service) (entry cache.Entry, err error) {
-	shortDeviceId, err := shortenId(deviceId)
+func (wrapper *Wrapper) GetLastMessage(ctx context.Context, deviceId string, serviceId string, service models.Service) (entry cache.Entry, err error) {
+	table, err := hypertableName(nil, &deviceId, &serviceId, "")
 	if err != nil {
 		return entry, err
 	}
-	shortServiceId, err := shortenId(serviceId)
+	jsonValues, err := wrapper.lastRow(ctx, table)
 	if err != nil {
 		return entry, err
 	}
-	var rawValues string
-	err = wrapper.pool.QueryRow("select to_json(r) from (select * from \"device:" + shortDeviceId + "_service:" + shortServiceId + "\" order by time desc limit 1) r;").Scan(&rawValues)
-	if err != nil {
-		return entry, err
-	}
-	var jsonValues map[string]interface{}
-	err = json.Unmarshal([]byte(rawValues), &jsonValues)
-	if err != nil {
-		return entry, err
-	}
-	ti, ok := jsonValues["time"]
-	if !ok {
-		return entry, fmt.Errorf("values are missing time %s", rawValues)
-	}
-	t, ok := ti.(string)
-	if !ok {
-		return entry, fmt.Errorf("field time is not string %v", ti)
-	}
-	entry.Time, err = time.Parse(time.RFC3339, t)
+	entry.Time, err = rowTime(jsonValues)
 	if err != nil {
 		return entry, err
 	}
@@ -70,6 +53,54 @@ func (wrapper *Wrapper) GetLastMessage(deviceId string, serviceId string, servic
 	return
 }
 
+// GetLastExportMessage reads the last row of an export, ownerUserId has to be the owner of the export.
+// Values are keyed by column name, names hashed by the table worker stay hashed.
+func (wrapper *Wrapper) GetLastExportMessage(ctx context.Context, exportId string, ownerUserId string) (entry cache.Entry, err error) {
+	table, err := hypertableName(&exportId, nil, nil, ownerUserId)
+	if err != nil {
+		return entry, err
+	}
+	jsonValues, err := wrapper.lastRow(ctx, table)
+	if err != nil {
+		return entry, err
+	}
+	entry.Time, err = rowTime(jsonValues)
+	if err != nil {
+		return entry, err
+	}
+	delete(jsonValues, "time")
+	entry.Value = jsonValues
+	return
+}
+
+// lastRow returns the latest row of table with column names as keys
+func (wrapper *Wrapper) lastRow(ctx context.Context, table string) (jsonValues map[string]interface{}, err error) {
+	var rawValues string
+	release, err := wrapper.scheduler.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	err = wrapper.pool.QueryRowEx(ctx, "select to_json(r) from (select * from \""+table+"\" order by time desc limit 1) r;", nil).Scan(&rawValues)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(rawValues), &jsonValues)
+	return jsonValues, err
+}
+
+func rowTime(jsonValues map[string]interface{}) (time.Time, error) {
+	ti, ok := jsonValues["time"]
+	if !ok {
+		return time.Time{}, fmt.Errorf("values are missing time %v", jsonValues)
+	}
+	t, ok := ti.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("field time is not string %v", ti)
+	}
+	return time.Parse(time.RFC3339, t)
+}
+
 func buildOutput(cv models.ContentVariable, jsonValues map[string]interface{}, path string) (result map[string]interface{}, err error) {
 	result = map[string]interface{}{}
 	if len(path) > 0 {
@@ -17,6 +17,7 @@
 package timescale
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
@@ -26,6 +27,7 @@ import (
 	importModel "github.com/SENERGY-Platform/import-repository/lib/model"
 	"github.com/SENERGY-Platform/service-commons/pkg/jwt"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx"
@@ -37,21 +39,25 @@ type distance struct {
 	km         float64
 }
 
-func (wrapper *Wrapper) CreateFiltersForImport(exportId string, userId string, token string, lat float64, lon float64) ([]model.QueriesRequestElementFilter, error) {
+func (wrapper *Wrapper) CreateFiltersForImport(ctx context.Context, exportId string, userId string, token string, lat float64, lon float64) ([]model.QueriesRequestElementFilter, error) {
 	if wrapper.config.Debug {
 		start := time.Now()
 		defer func() {
 			log.Logger.Debug(fmt.Sprintf("CreateFiltersForImport took %v, is included in query generation", time.Since(start)))
 		}()
 	}
+	upstreamStart := time.Now()
 	exportInstance, err := wrapper.servingClient.GetInstance(token, exportId)
+	metrics.ObserveUpstream(metrics.Serving, upstreamStart, err)
 	if err != nil {
 		return nil, err
 	}
 	if !strings.HasPrefix(exportInstance.ServiceName, "urn:infai:ses:import-type:") {
 		return nil, errors.New("can not locate export which is not based on an import")
 	}
+	upstreamStart = time.Now()
 	importType, err, _ := wrapper.importRepoClient.ReadImportType(exportInstance.ServiceName, jwt.Token{Token: token})
+	metrics.ObserveUpstream(metrics.ImportRepository, upstreamStart, err)
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +106,7 @@ func (wrapper *Wrapper) CreateFiltersForImport(exportId string, userId string, t
 		return nil, errors.New("missing identifier, lat or lon path in export")
 	}
 
-	tableName, err := wrapper.tableName(model.QueriesRequestElement{ExportId: &exportId}, exportInstance.UserId, wrapper.config.DefaultTimezone)
+	tableName, _, err := wrapper.tableName(ctx, model.QueriesRequestElement{ExportId: &exportId}, exportInstance.UserId, wrapper.config.DefaultTimezone)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +114,7 @@ func (wrapper *Wrapper) CreateFiltersForImport(exportId string, userId string, t
 	if wrapper.config.Debug {
 		log.Logger.Debug("Querying export of import locations with: " + query)
 	}
-	table, err := wrapper.ExecuteQuery(query)
+	table, err := wrapper.ExecuteQuery(ctx, query)
 	if err != nil {
 		err2, ok := err.(pgx.PgError)
 		if !ok || err2.Code != pgerrcode.UndefinedTable {
@@ -121,7 +127,7 @@ func (wrapper *Wrapper) CreateFiltersForImport(exportId string, userId string, t
 		if err != nil {
 			return nil, err
 		}
-		table, err = wrapper.ExecuteQuery(query)
+		table, err = wrapper.ExecuteQuery(ctx, query)
 		if err != nil {
 			return nil, err
 		}
@@ -29,6 +29,21 @@ const wrapperMaterializedViewProcedureName = "ts_wrapper_refresh_mat_view"
 type Wrapper struct {
 	config           configuration.Config
 	pool             *pgx.ConnPool
+	scheduler        *scheduler // limits the database operations in flight, nil if disabled
 	importRepoClient importRepo.Interface
 	servingClient    *serving.Client
 }
+
+// Query is a generated SQL statement with its positional bind arguments ($1, $2, ...)
+type Query struct {
+	Sql                 string
+	Args                []interface{}
+	Table               string // hypertable or continuous aggregate view the query reads from
+	ContinuousAggregate bool   // true if Table is a continuous aggregate view picked by tableName
+}
+
+// Field describes a column of a query result
+type Field struct {
+	Name         string
+	DataTypeName string // postgres type name, e.g. timestamptz or float8
+}
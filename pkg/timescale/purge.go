@@ -0,0 +1,180 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package timescale
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
+	"github.com/jackc/pgx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// PurgeData deletes the data of a device, a device service or an export. ownerUserId has to be the owner of an export.
+// Without time range, the tables are truncated, their continuous aggregates are dropped and materialized views are refreshed.
+// With time range, only rows in the range are deleted and all views are refreshed, so they no longer contain the data either.
+// The tables themselves are kept, the tableworker expects them to exist.
+func (wrapper *Wrapper) PurgeData(ctx context.Context, request model.PurgeRequest, ownerUserId string) (result model.PurgeResponse, err error) {
+	ctx, span := tracing.Start(ctx, "Wrapper.PurgeData", attribute.Bool("entire", request.Entire()))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	result = model.PurgeResponse{Tables: []string{}, ContinuousAggregates: []string{}, MaterializedViews: []string{}}
+	result.Tables, err = wrapper.purgeTables(ctx, request, ownerUserId)
+	if err != nil {
+		return result, err
+	}
+	if request.ExportId == nil {
+		if request.ServiceId != nil && len(result.Tables) > 0 {
+			result.ServiceIds = []string{*request.ServiceId}
+		} else {
+			for _, table := range result.Tables {
+				serviceMatches := serviceRegex.FindStringSubmatch(table)
+				if len(serviceMatches) < 2 {
+					continue
+				}
+				longServiceId, err := models.LongId(serviceMatches[1])
+				if err != nil {
+					return result, err
+				}
+				result.ServiceIds = append(result.ServiceIds, servicePrefix+longServiceId)
+			}
+		}
+	}
+	var start, end time.Time
+	if !request.Entire() {
+		start, err = time.Parse(time.RFC3339, *request.Start)
+		if err != nil {
+			return result, err
+		}
+		end, err = time.Parse(time.RFC3339, *request.End)
+		if err != nil {
+			return result, err
+		}
+		var deletedRows int64
+		result.DeletedRows = &deletedRows
+	}
+	for _, table := range result.Tables {
+		continuousAggregates, materializedViews, err := wrapper.purgeViews(ctx, table)
+		if err != nil {
+			return result, err
+		}
+		if request.Entire() {
+			err = wrapper.purgeTableEntirely(ctx, table, continuousAggregates, materializedViews)
+		} else {
+			var deletedRows int64
+			deletedRows, err = wrapper.purgeTableRange(ctx, table, continuousAggregates, materializedViews, start, end)
+			*result.DeletedRows += deletedRows
+		}
+		if err != nil {
+			return result, err
+		}
+		result.ContinuousAggregates = append(result.ContinuousAggregates, continuousAggregates...)
+		result.MaterializedViews = append(result.MaterializedViews, materializedViews...)
+	}
+	return result, nil
+}
+
+// purgeTables returns the existing hypertables of the request. Requests for a device without service match the tables of all its services.
+func (wrapper *Wrapper) purgeTables(ctx context.Context, request model.PurgeRequest, ownerUserId string) (tables []string, err error) {
+	tables = []string{}
+	scan := func(rows *pgx.Rows) error {
+		var table string
+		err := rows.Scan(&table)
+		tables = append(tables, table)
+		return err
+	}
+	if request.ExportId != nil || request.ServiceId != nil {
+		table, err := hypertableName(request.ExportId, request.DeviceId, request.ServiceId, ownerUserId)
+		if err != nil {
+			return nil, err
+		}
+		err = wrapper.queryRows(ctx, scan, "SELECT table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE' AND table_name = $1", table)
+		return tables, err
+	}
+	shortDeviceId, err := shortenId(*request.DeviceId)
+	if err != nil {
+		return nil, err
+	}
+	err = wrapper.queryRows(ctx, scan, "SELECT table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE' AND table_name ~ $1", "^device:"+regexp.QuoteMeta(shortDeviceId)+"_service:.{22}$")
+	return tables, err
+}
+
+// purgeViews returns the continuous aggregates of table and the materialized view created by setupMaterializedRefreshJob, if any
+func (wrapper *Wrapper) purgeViews(ctx context.Context, table string) (continuousAggregates []string, materializedViews []string, err error) {
+	continuousAggregates = []string{}
+	err = wrapper.queryRows(ctx, func(rows *pgx.Rows) error {
+		var view string
+		err := rows.Scan(&view)
+		continuousAggregates = append(continuousAggregates, view)
+		return err
+	}, "SELECT view_name FROM timescaledb_information.continuous_aggregates WHERE hypertable_name = $1", table)
+	if err != nil {
+		return nil, nil, err
+	}
+	materializedViews = []string{}
+	err = wrapper.queryRows(ctx, func(rows *pgx.Rows) error {
+		var view string
+		err := rows.Scan(&view)
+		materializedViews = append(materializedViews, view)
+		return err
+	}, "SELECT matviewname FROM pg_matviews WHERE matviewname = $1", wrapperMaterializedViewPrefix+table)
+	return continuousAggregates, materializedViews, err
+}
+
+// purgeTableEntirely truncates table in one transaction, so a failing step doesn't leave the table with dropped views.
+// The materialized view is refreshed instead of dropped, locating imports depends on it.
+func (wrapper *Wrapper) purgeTableEntirely(ctx context.Context, table string, continuousAggregates []string, materializedViews []string) error {
+	_, err := wrapper.execInTransaction(ctx, generatePurgeEntirely(table, continuousAggregates, materializedViews))
+	return err
+}
+
+func generatePurgeEntirely(table string, continuousAggregates []string, materializedViews []string) (queries []Query) {
+	for _, view := range continuousAggregates {
+		queries = append(queries, Query{Sql: "DROP MATERIALIZED VIEW IF EXISTS \"" + view + "\"", Table: table})
+	}
+	queries = append(queries, Query{Sql: "TRUNCATE \"" + table + "\"", Table: table})
+	for _, view := range materializedViews {
+		queries = append(queries, Query{Sql: "REFRESH MATERIALIZED VIEW \"" + view + "\"", Table: table})
+	}
+	return queries
+}
+
+func (wrapper *Wrapper) purgeTableRange(ctx context.Context, table string, continuousAggregates []string, materializedViews []string, start time.Time, end time.Time) (deletedRows int64, err error) {
+	query := generateDeleteRange(table, start, end)
+	deletedRows, err = wrapper.exec(ctx, query.Sql, query.Args...)
+	if err != nil {
+		return 0, err
+	}
+	for _, view := range continuousAggregates {
+		_, err = wrapper.exec(ctx, "CALL refresh_continuous_aggregate($1::text::regclass, $2::timestamptz, $3::timestamptz)", "\""+view+"\"", start, end)
+		if err != nil {
+			return deletedRows, err
+		}
+	}
+	for _, view := range materializedViews {
+		_, err = wrapper.exec(ctx, "REFRESH MATERIALIZED VIEW \""+view+"\"")
+		if err != nil {
+			return deletedRows, err
+		}
+	}
+	return deletedRows, nil
+}
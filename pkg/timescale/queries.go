@@ -17,6 +17,7 @@
 package timescale
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -29,10 +30,21 @@ import (
 	"github.com/SENERGY-Platform/models/go/models"
 	util "github.com/SENERGY-Platform/timescale-tableworker/pkg/lib/handler"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func translateFunctionName(name string) string {
+	if fraction, approx, ok := model.ParsePercentile(name); ok {
+		p := strconv.FormatFloat(fraction, 'g', 15, 64) // hides rounding errors of the division
+		if approx {
+			// percentile_agg is a uddsketch with default size and error
+			return "approx_percentile(" + p + ", percentile_agg("
+		}
+		return "percentile_disc(" + p + ") WITHIN GROUP (ORDER BY "
+	}
 	switch name {
 	case "mean":
 		return "avg("
@@ -42,17 +54,58 @@ func translateFunctionName(name string) string {
 		return "average(time_weight('Linear', \"time\", "
 	case "time-weighted-mean-locf":
 		return "average(time_weight('LOCF', \"time\", "
+	case "mode":
+		return "mode() WITHIN GROUP (ORDER BY "
+	case "count-distinct":
+		return "count(DISTINCT "
+	case "skewness", "kurtosis":
+		return name + "(stats_agg("
 	default:
-		if strings.HasPrefix(name, "difference") {
-			parts := strings.Split(name, "-")
-			return parts[1] + "("
+		if strings.HasPrefix(name, "difference-") {
+			return translateFunctionName(strings.TrimPrefix(name, "difference-"))
 		}
 		return name + "("
 	}
 }
 
-func (wrapper *Wrapper) GenerateQueries(elements []model.QueriesRequestElement, userId string, ownerUserIds []string, forceTz string, devices []models.Device) (queries []string, err error) {
-	queries = make([]string, len(elements))
+// translateFunctionSuffix closes the parentheses opened by translateFunctionName
+func translateFunctionSuffix(name string) string {
+	name = strings.TrimPrefix(name, "difference-")
+	if _, approx, ok := model.ParsePercentile(name); ok && approx {
+		return "))"
+	}
+	if strings.HasPrefix(name, "time-weighted-") || name == "skewness" || name == "kurtosis" {
+		return "))"
+	}
+	return ")"
+}
+
+// rollupGroupType returns true if values of groupType can be aggregated again from a continuous aggregate with smaller buckets
+func rollupGroupType(name string) bool {
+	name = strings.TrimPrefix(name, "difference-")
+	if _, _, ok := model.ParsePercentile(name); ok {
+		return false
+	}
+	switch name {
+	case "mean", "stddev", "variance", "mode", "count-distinct", "skewness", "kurtosis":
+		return false
+	default:
+		return true
+	}
+}
+
+// bind appends value to the bind arguments of a query and returns the matching placeholder
+func bind(args *[]interface{}, value interface{}) string {
+	*args = append(*args, value)
+	return "$" + strconv.Itoa(len(*args))
+}
+
+func (wrapper *Wrapper) GenerateQueries(ctx context.Context, elements []model.QueriesRequestElement, userId string, ownerUserIds []string, forceTz string, devices []models.Device) (queries []Query, err error) {
+	ctx, span := tracing.Start(ctx, "Wrapper.GenerateQueries", attribute.Int("elements", len(elements)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	queries = make([]Query, len(elements))
 	for i, element := range elements {
 		var timezone string
 		if len(forceTz) > 0 {
@@ -64,18 +117,31 @@ func (wrapper *Wrapper) GenerateQueries(elements []model.QueriesRequestElement,
 				timezone = wrapper.config.DefaultTimezone // no special tz support for exports
 			}
 		}
-		table, err := wrapper.tableName(element, ownerUserIds[i], timezone)
+		table, continuousAggregate, err := wrapper.tableName(ctx, element, ownerUserIds[i], timezone)
 		if err != nil {
 			return queries, err
 		}
 
 		query := ""
+		args := []interface{}{}
 		query += "SELECT "
 		if element.GroupTime != nil {
 			zero := 0
 			asc := model.Asc
 			desc := model.Desc
-			query += "sub0.time AS \"time\", "
+			if element.GapFilled() && len(element.Columns) > 1 {
+				// columns without fill might miss buckets of filled columns
+				query += "COALESCE("
+				for idx := range element.Columns {
+					if idx > 0 {
+						query += ", "
+					}
+					query += "sub" + strconv.Itoa(idx) + ".time"
+				}
+				query += ") AS \"time\", "
+			} else {
+				query += "sub0.time AS \"time\", "
+			}
 			for idx, column := range element.Columns {
 				if column.GroupType == nil {
 					return nil, errors.New("mixing aggregate and non-aggregate queries is not supported\n")
@@ -83,11 +149,7 @@ func (wrapper *Wrapper) GenerateQueries(elements []model.QueriesRequestElement,
 				if idx > 0 {
 					query += ", "
 				}
-				query += "(sub" + strconv.Itoa(idx) + ".value"
-				if strings.HasPrefix(*column.GroupType, "difference") {
-					query += " - lag(sub" + strconv.Itoa(idx) + ".value) OVER (ORDER BY 1)"
-				}
-				query += ") "
+				query += "(" + differenceExpression(column, "sub"+strconv.Itoa(idx)+".value", &args) + ") "
 				if column.Math != nil {
 					query += *column.Math + " "
 				}
@@ -98,13 +160,13 @@ func (wrapper *Wrapper) GenerateQueries(elements []model.QueriesRequestElement,
 			var l *int
 			for idx, column := range element.Columns {
 				hashedColumnName := util.HashFieldNameIfNeeded(column.Name)
-				query += "(SELECT time_bucket('" + *element.GroupTime + "', \"time\", '" + timezone + "') AS \"time\", "
+				aggregate := ""
 				if strings.HasPrefix(*column.GroupType, "difference") {
-					groupParts := strings.Split(*column.GroupType, "-")
-					if groupParts[1] == "first" || groupParts[1] == "last" {
-						query += groupParts[1] + "(" + hashedColumnName + ", \"time\")"
+					baseGroupType := strings.TrimPrefix(*column.GroupType, "difference-")
+					if baseGroupType == "first" || baseGroupType == "last" {
+						aggregate += baseGroupType + "(" + hashedColumnName + ", \"time\")"
 					} else {
-						query += translateFunctionName(groupParts[1]) + hashedColumnName + ")"
+						aggregate += translateFunctionName(baseGroupType) + hashedColumnName + translateFunctionSuffix(baseGroupType)
 					}
 					if element.Time != nil && (element.Time.Last != nil || element.Time.Ahead != nil) && !elementTimeLastAheadModified {
 						// manually increase the last offset by 1 to ensure unified results
@@ -194,33 +256,43 @@ func (wrapper *Wrapper) GenerateQueries(elements []model.QueriesRequestElement,
 							diffT = endT.Year() - startT.Year()
 						}
 						l = &diffT
+						if element.GroupOrigin != nil || element.GroupOffset != nil {
+							// include the complete bucket before the requested range
+							start, err = alignToBucket(start, *element.GroupTime, element.GroupOrigin, element.GroupOffset, timezone)
+							if err != nil {
+								return nil, err
+							}
+						}
 						startS := start.Format(time.RFC3339)
 						element.Time.Start = &startS
 						endS := end.Format(time.RFC3339)
 						element.Time.EndOriginal = element.Time.End
 						element.Time.End = &endS
 						elements[i] = element
+						elementTimeLastAheadModified = true // widen once, all columns share the time range
 					}
 				} else if *column.GroupType == "first" || *column.GroupType == "last" {
-					query += *column.GroupType + "(" + hashedColumnName + ", \"time\")"
-				} else if strings.HasPrefix(*column.GroupType, "time-weighted-") {
-					query += translateFunctionName(*column.GroupType) + hashedColumnName + "))"
+					aggregate += *column.GroupType + "(" + hashedColumnName + ", \"time\")"
 				} else {
-					query += translateFunctionName(*column.GroupType) + hashedColumnName + ")"
+					aggregate += translateFunctionName(*column.GroupType) + hashedColumnName + translateFunctionSuffix(*column.GroupType)
+				}
+				bucket, aggregate, err := fillColumn(element, column, aggregate, timezone, &args)
+				if err != nil {
+					return nil, err
 				}
-				query += " AS value"
+				query += "(SELECT " + bucket + " AS \"time\", " + aggregate + " AS value"
 				query += " FROM \"" + table + "\""
 				filterString := ""
 				if l != nil {
 					n := *l
 					n += 2
-					filterString, err = getFilterString(element, true, &zero, &asc, &n)
+					filterString, err = getFilterString(element, true, &zero, &asc, &n, &args)
 				} else {
 					dir := asc
 					if column.GroupType != nil && *column.GroupType == "last" {
 						dir = desc
 					}
-					filterString, err = getFilterString(element, true, &zero, &dir, nil)
+					filterString, err = getFilterString(element, true, &zero, &dir, nil, &args)
 				}
 				if err != nil {
 					return nil, err
@@ -260,18 +332,78 @@ func (wrapper *Wrapper) GenerateQueries(elements []model.QueriesRequestElement,
 				query += " AS \"" + column.Name + "\""
 			}
 			query += " FROM \"" + table + "\""
-			filterString, err := getFilterString(element, false, nil, nil, nil)
+			filterString, err := getFilterString(element, false, nil, nil, nil, &args)
 			if err != nil {
 				return nil, err
 			}
 			query += filterString
 		}
-		queries[i] = query
+		queries[i] = Query{Sql: query, Args: args, Table: table, ContinuousAggregate: continuousAggregate}
 	}
 	return
 }
 
-func getFilterString(element model.QueriesRequestElement, group bool, overrideSortIndex *int, overrideOrderDirection *model.Direction, overrideLimit *int) (query string, err error) {
+// differenceExpression returns the value of a grouped column, difference group types subtract the previous value.
+// If the column is a counter, a decreasing value is treated as reset or as rollover at column.CounterMax.
+// On a reset the new value is the difference, on a rollover the difference is counted up to column.CounterMax.
+func differenceExpression(column model.QueriesRequestElementColumn, value string, args *[]interface{}) string {
+	if !strings.HasPrefix(*column.GroupType, "difference") {
+		return value
+	}
+	previous := "lag(" + value + ") OVER (ORDER BY 1)"
+	if !column.CounterReset && column.CounterMax == nil {
+		return value + " - " + previous
+	}
+	reset := value
+	if column.CounterMax != nil {
+		reset += " + " + bind(args, *column.CounterMax) + "::double precision - " + previous
+	}
+	return "CASE WHEN " + value + " < " + previous + " THEN " + reset + " ELSE " + value + " - " + previous + " END"
+}
+
+// fillColumn returns the time bucket and the aggregate expression of a grouped column.
+// Columns with fill use time_bucket_gapfill, which needs the time range of the element as bounds.
+func fillColumn(element model.QueriesRequestElement, column model.QueriesRequestElementColumn, aggregate string, timezone string, args *[]interface{}) (bucket string, filled string, err error) {
+	if column.Fill == nil || *column.Fill == model.FillNone {
+		bucket = "time_bucket(" + bind(args, *element.GroupTime) + "::interval, \"time\", " + bind(args, timezone) + "::text"
+		if element.GroupOrigin != nil {
+			bucket += ", origin => " + bind(args, *element.GroupOrigin) + "::timestamptz"
+		}
+		if element.GroupOffset != nil {
+			bucket += ", \"offset\" => " + bind(args, *element.GroupOffset) + "::interval"
+		}
+		return bucket + ")", aggregate, nil
+	}
+	if element.Time == nil {
+		return "", "", errors.New("fill requires a time range")
+	}
+	bucket = "time_bucket_gapfill(" + bind(args, *element.GroupTime) + "::interval, \"time\", " + bind(args, timezone) + "::text, "
+	if element.Time.Last != nil {
+		bucket += "now() - " + bind(args, *element.Time.Last) + "::interval, now())"
+	} else if element.Time.Ahead != nil {
+		bucket += "now(), now() + " + bind(args, *element.Time.Ahead) + "::interval)"
+	} else {
+		bucket += bind(args, *element.Time.Start) + "::timestamptz, " + bind(args, *element.Time.End) + "::timestamptz)"
+	}
+	switch *column.Fill {
+	case model.FillNull:
+		filled = aggregate
+	case model.FillPrevious:
+		filled = "locf(" + aggregate + ")"
+	case model.FillLinear:
+		filled = "interpolate(" + aggregate + ")"
+	default:
+		// bound as double precision like filter values, the aggregate of integer columns would not accept fractions otherwise
+		constant, err := strconv.ParseFloat(*column.Fill, 64)
+		if err != nil {
+			return "", "", err
+		}
+		filled = "COALESCE(" + aggregate + ", " + bind(args, constant) + "::double precision)"
+	}
+	return bucket, filled, nil
+}
+
+func getFilterString(element model.QueriesRequestElement, group bool, overrideSortIndex *int, overrideOrderDirection *model.Direction, overrideLimit *int, args *[]interface{}) (query string, err error) {
 	if (element.Filters != nil && len(*element.Filters) > 0) || element.Time != nil {
 		query += " WHERE "
 	}
@@ -280,18 +412,11 @@ func getFilterString(element model.QueriesRequestElement, group bool, overrideSo
 			if idx != 0 {
 				query += " AND "
 			}
-			_, valueIsString := filter.Value.(string)
 			query += util.HashFieldNameIfNeeded(filter.Column)
 			if filter.Math != nil {
 				query += *filter.Math + " "
 			}
-			query += filter.Type
-			if valueIsString {
-				query += " '" + filter.Value.(string) + "'"
-			} else {
-				value := fmt.Sprintf("%v", filter.Value)
-				query += " " + value
-			}
+			query += filter.Type + " " + bindFilterValue(args, filter.Value)
 		}
 	}
 	if element.Time != nil {
@@ -299,17 +424,28 @@ func getFilterString(element model.QueriesRequestElement, group bool, overrideSo
 			query += " AND "
 		}
 		if element.Time.Last != nil {
-			query += "\"time\" > now() - interval '" + *element.Time.Last + "'"
+			query += "\"time\" > now() - " + bind(args, *element.Time.Last) + "::interval"
 		} else if element.Time.Ahead != nil {
-			query += "\"time\" > now() AND \"time\" < now() + interval '" + *element.Time.Ahead + "'"
+			query += "\"time\" > now() AND \"time\" < now() + " + bind(args, *element.Time.Ahead) + "::interval"
 		} else {
-			query += "\"time\" > '" + *element.Time.Start + "' AND \"time\" < '" + *element.Time.End + "'"
+			query += "\"time\" > " + bind(args, *element.Time.Start) + "::timestamptz AND \"time\" < " + bind(args, *element.Time.End) + "::timestamptz"
 		}
 	}
 	query += getOrderLimitString(element, group, overrideSortIndex, overrideOrderDirection, overrideLimit)
 	return
 }
 
+// bindFilterValue binds numbers as double precision. Parameters are typed like the compared column otherwise,
+// and fractional values could not be encoded for integer columns.
+func bindFilterValue(args *[]interface{}, value interface{}) string {
+	switch value.(type) {
+	case string, bool, nil:
+		return bind(args, value)
+	default:
+		return bind(args, value) + "::double precision"
+	}
+}
+
 func getOrderLimitString(element model.QueriesRequestElement, group bool, overrideOrderIndex *int, overrideOrderDirection *model.Direction, overrideLimit *int) (query string) {
 	if group {
 		query += " GROUP BY 1"
@@ -333,6 +469,13 @@ func getOrderLimitString(element model.QueriesRequestElement, group bool, overri
 
 	if orderIndex != -1 {
 		query += " ORDER BY " + strconv.Itoa(orderIndex+1) + " " + strings.ToUpper(string(orderDirection))
+		if element.StableOrder {
+			for i := 0; i <= len(element.Columns); i++ {
+				if i != orderIndex {
+					query += ", " + strconv.Itoa(i+1) + " ASC"
+				}
+			}
+		}
 	}
 	if overrideLimit != nil {
 		query += " LIMIT " + strconv.Itoa(*overrideLimit)
@@ -342,51 +485,79 @@ func getOrderLimitString(element model.QueriesRequestElement, group bool, overri
 	return
 }
 
-func (wrapper *Wrapper) tableName(element model.QueriesRequestElement, userId string, timezone string) (table string, err error) {
-	if element.ExportId != nil {
-		shortUserId, err := shortenId(userId)
-		if err != nil {
-			return "", err
-		}
-		shortExportId, err := shortenId(*element.ExportId)
-		if err != nil {
-			return "", err
-		}
-		table = "userid:" + shortUserId + "_" + "export:" + shortExportId
-	} else {
-		shortDeviceId, err := shortenId(*element.DeviceId)
-		if err != nil {
-			return "", err
-		}
-		shortServiceId, err := shortenId(*element.ServiceId)
-		if err != nil {
-			return "", err
-		}
-		table = "device:" + shortDeviceId + "_" + "service:" + shortServiceId
+// OrderedQuery sorts the rows of query by the column orderColumnIndex and limits them, like the buffered response
+// does after reading them. Empty values are sorted first in ascending order, as in model.Sort2D.
+func OrderedQuery(query Query, orderColumnIndex int, orderDirection model.Direction, limit *int) Query {
+	nulls := " NULLS FIRST"
+	if orderDirection == model.Desc {
+		nulls = " NULLS LAST"
+	}
+	query.Sql = "SELECT * FROM (" + query.Sql + ") AS unordered ORDER BY " + strconv.Itoa(orderColumnIndex+1) + " " + strings.ToUpper(string(orderDirection)) + nulls
+	if limit != nil {
+		query.Sql += " LIMIT " + strconv.Itoa(*limit)
+	}
+	return query
+}
+
+func (wrapper *Wrapper) tableName(ctx context.Context, element model.QueriesRequestElement, userId string, timezone string) (table string, continuousAggregate bool, err error) {
+	table, err = hypertableName(element.ExportId, element.DeviceId, element.ServiceId, userId)
+	if err != nil {
+		return "", false, err
 	}
 	if element.GroupTime != nil && wrapper.pool != nil {
 		// check if CA View available
-		query, err := getCAQuery(element, table, timezone)
+		query, args, err := getCAQuery(element, table, timezone)
 		if err != nil {
 			log.Logger.Warn("getCAQuery failed", "error", err)
-			return table, nil
+			return table, false, nil
 		}
 
 		var caTable string
 		if wrapper.config.Debug {
-			log.Logger.Debug("Checking for CA View with: " + query)
+			log.Logger.Debug("Checking for CA View with: "+query, "args", args)
 		}
-		err = wrapper.pool.QueryRow(query).Scan(&caTable)
+		release, err := wrapper.scheduler.acquire(ctx)
+		if err != nil {
+			return table, false, err
+		}
+		err = wrapper.pool.QueryRowEx(ctx, query, nil, args...).Scan(&caTable)
+		release()
+		metrics.ObserveContinuousAggregate(err == nil)
 		if err == nil {
-			return caTable, nil
+			return caTable, true, nil
 		} else {
-			return table, nil
+			return table, false, nil
 		}
 	}
-	return table, nil
+	return table, false, nil
 
 }
 
+// hypertableName returns the table the tableworker writes a device service or an export to.
+// Exports are stored per owner, userId has to be the owner of the export.
+func hypertableName(exportId *string, deviceId *string, serviceId *string, userId string) (string, error) {
+	if exportId != nil {
+		shortUserId, err := shortenId(userId)
+		if err != nil {
+			return "", err
+		}
+		shortExportId, err := shortenId(*exportId)
+		if err != nil {
+			return "", err
+		}
+		return "userid:" + shortUserId + "_" + "export:" + shortExportId, nil
+	}
+	shortDeviceId, err := shortenId(*deviceId)
+	if err != nil {
+		return "", err
+	}
+	shortServiceId, err := shortenId(*serviceId)
+	if err != nil {
+		return "", err
+	}
+	return "device:" + shortDeviceId + "_" + "service:" + shortServiceId, nil
+}
+
 func shortenId(uuid string) (string, error) {
 	parts := strings.Split(uuid, ":")
 	noPrefix := parts[len(parts)-1]
@@ -398,36 +569,42 @@ func shortenId(uuid string) (string, error) {
 	return base64.RawURLEncoding.EncodeToString(bytes), nil
 }
 
-func getCAQuery(element model.QueriesRequestElement, table string, timezone string) (string, error) {
-	query := "SELECT view_name FROM (SELECT view_name, substring(view_definition, 'time_bucket\\((.*?)::interval, \"time\", ''" + timezone + "''')::interval as bucket FROM timescaledb_information.continuous_aggregates WHERE hypertable_name = '" + table + "' "
+func getCAQuery(element model.QueriesRequestElement, table string, timezone string) (query string, args []interface{}, err error) {
+	args = []interface{}{}
+	query = "SELECT view_name FROM (SELECT view_name, substring(view_definition, " + bind(&args, "time_bucket\\((.*?)::interval, \"time\", '"+regexp.QuoteMeta(timezone)+"'") + "::text)::interval as bucket FROM timescaledb_information.continuous_aggregates WHERE hypertable_name = " + bind(&args, table) + " "
 
+	if element.GroupOrigin != nil || element.GroupOffset != nil {
+		// buckets of continuous aggregates are aligned to the default origin
+		return table, nil, errors.New("custom bucket origin or offset not supported by continuous aggregates")
+	}
 	for _, column := range element.Columns {
 		if column.GroupType == nil {
-			return table, errors.New("expected all columns to contain GroupType")
+			return table, nil, errors.New("expected all columns to contain GroupType")
 		}
-		if *column.GroupType == "mean" {
+		if !rollupGroupType(*column.GroupType) {
 			// not implemented
-			return table, errors.New("")
+			return table, nil, errors.New("")
 		}
-		query += "AND view_definition LIKE '%" + strings.ReplaceAll(translateFunctionName(*column.GroupType), "'", "''")
+		pattern := "%" + translateFunctionName(*column.GroupType)
 		containsDot := strings.Contains(column.Name, ".")
 		if containsDot {
-			query += "\"" + column.Name + "\""
+			pattern += "\"" + column.Name + "\""
 		} else {
-			query += column.Name
+			pattern += column.Name
 		}
-		query += ", \"time\") AS "
+		pattern += ", \"time\") AS "
 		if containsDot {
-			query += "\"" + column.Name + "\""
+			pattern += "\"" + column.Name + "\""
 		} else {
-			query += column.Name
+			pattern += column.Name
 		}
-		query += "%'"
+		pattern += "%"
+		query += "AND view_definition LIKE " + bind(&args, pattern) + " "
 	}
-	query += ") sub WHERE bucket <= '" + *element.GroupTime + "'::interval ORDER BY bucket DESC"
+	query += ") sub WHERE bucket <= " + bind(&args, *element.GroupTime) + "::interval ORDER BY bucket DESC"
 	query += " LIMIT 1;"
 
-	return query, nil
+	return query, args, nil
 }
 
 func getTZ(deviceId string, devices []models.Device, defaultTZ string) string {
@@ -17,8 +17,10 @@
 package timescale
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/SENERGY-Platform/models/go/models"
@@ -117,18 +119,19 @@ func TestQueries(t *testing.T) {
 			OrderDirection:   &asc,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT \"time\", \"sensor.ENERGY.Total\"+5 AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"+10" +
+		expected := Query{Sql: "SELECT \"time\", \"sensor.ENERGY.Total\"+5 AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"+10" +
 			" AS \"sensor.ENERGY.Total\" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE" +
-			" \"sensor.ENERGY.Total\"+5 > 10 AND \"time\" > now() - interval '1d' ORDER BY 1 ASC LIMIT 10"
+			" \"sensor.ENERGY.Total\"+5 > $1::double precision AND \"time\" > now() - $2::interval ORDER BY 1 ASC LIMIT 10",
+			Args: []interface{}{ten, d1}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
 
-		if actual[0] != expected {
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n", expected, "\n", actual)
 		}
 	})
@@ -163,25 +166,26 @@ func TestQueries(t *testing.T) {
 				OrderDirection:   &asc,
 			}}
 
-			actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+			actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 			if err != nil {
 				t.Error(err)
 			}
 			if len(actual) != 1 {
 				t.Error("Unexpected number of queries", len(actual))
 			}
-			expected := fmt.Sprintf("SELECT sub0.time AS \"time\", "+
-				"(sub0.value) AS \"sensor.ENERGY.Total\", "+
-				"(sub1.value) AS \"sensor.ENERGY.Total\" "+
-				"FROM (SELECT time_bucket('%s', \"time\", 'Europe/Berlin') AS \"time\", "+
-				"avg(\"sensor.ENERGY.Total\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" "+
-				"WHERE \"time\" > now() - interval '10d' GROUP BY 1 ORDER BY 1 ASC) sub0 FULL OUTER JOIN "+
-				"(SELECT time_bucket('%s', \"time\", 'Europe/Berlin') AS \"time\", percentile_disc(0.5) WITHIN GROUP (ORDER BY "+
-				"\"sensor.ENERGY.Total\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" "+
-				"WHERE \"time\" > now() - interval '10d' GROUP BY 1 ORDER BY 1 ASC) sub1 on sub0.time = sub1.time "+
-				"ORDER BY 1 ASC", tc.GroupTime, tc.GroupTime)
-
-			if actual[0] != expected {
+			expected := Query{Sql: "SELECT sub0.time AS \"time\", " +
+				"(sub0.value) AS \"sensor.ENERGY.Total\", " +
+				"(sub1.value) AS \"sensor.ENERGY.Total\" " +
+				"FROM (SELECT time_bucket($1::interval, \"time\", $2::text) AS \"time\", " +
+				"avg(\"sensor.ENERGY.Total\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
+				"WHERE \"time\" > now() - $3::interval GROUP BY 1 ORDER BY 1 ASC) sub0 FULL OUTER JOIN " +
+				"(SELECT time_bucket($4::interval, \"time\", $5::text) AS \"time\", percentile_disc(0.5) WITHIN GROUP (ORDER BY " +
+				"\"sensor.ENERGY.Total\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
+				"WHERE \"time\" > now() - $6::interval GROUP BY 1 ORDER BY 1 ASC) sub1 on sub0.time = sub1.time " +
+				"ORDER BY 1 ASC",
+				Args: []interface{}{tc.GroupTime, "Europe/Berlin", d10, tc.GroupTime, "Europe/Berlin", d10}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
+
+			if !reflect.DeepEqual(actual[0], expected) {
 				t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0])
 			}
 		})
@@ -220,31 +224,32 @@ func TestQueries(t *testing.T) {
 			OrderDirection:   &desc,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT sub0.time AS \"time\", (sub0.value - lag(sub0.value) OVER (ORDER BY 1)) AS \"sensor.ENERGY.Total\"," +
+		expected := Query{Sql: "SELECT sub0.time AS \"time\", (sub0.value - lag(sub0.value) OVER (ORDER BY 1)) AS \"sensor.ENERGY.Total\"," +
 			" (sub1.value - lag(sub1.value) OVER (ORDER BY 1)) +5 AS \"sensor.ENERGY.Total\", (sub2.value) AS \"sensor.ENERGY.Total\"," +
 			" (sub3.value) AS \"sensor.ENERGY.Total\", (sub4.value - lag(sub4.value) OVER (ORDER BY 1)) AS \"sensor.ENERGY.Total\" " +
-			"FROM (SELECT time_bucket('1d', \"time\", 'Europe/Berlin') AS \"time\", last(\"sensor.ENERGY.Total\", \"time\") AS value FROM" +
-			" \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - interval '8d' GROUP BY " +
-			"1 ORDER BY 1 ASC LIMIT 9) sub0 FULL OUTER JOIN (SELECT time_bucket('1d', \"time\", 'Europe/Berlin') AS \"time\", " +
+			"FROM (SELECT time_bucket($1::interval, \"time\", $2::text) AS \"time\", last(\"sensor.ENERGY.Total\", \"time\") AS value FROM" +
+			" \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - $3::interval GROUP BY " +
+			"1 ORDER BY 1 ASC LIMIT 9) sub0 FULL OUTER JOIN (SELECT time_bucket($4::interval, \"time\", $5::text) AS \"time\", " +
 			"first(\"sensor.ENERGY.Total\", \"time\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
-			"WHERE \"time\" > now() - interval '8d' GROUP BY 1 ORDER BY 1 ASC LIMIT 9) sub1 on sub0.time = sub1.time FULL OUTER JOIN " +
-			"(SELECT time_bucket('1d', \"time\", 'Europe/Berlin') AS \"time\", first(\"sensor.ENERGY.Total\", \"time\") AS value FROM " +
-			"\"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - interval '8d' GROUP BY " +
-			"1 ORDER BY 1 ASC LIMIT 9) sub2 on sub0.time = sub2.time FULL OUTER JOIN (SELECT time_bucket('1d', \"time\", 'Europe/Berlin') AS" +
+			"WHERE \"time\" > now() - $6::interval GROUP BY 1 ORDER BY 1 ASC LIMIT 9) sub1 on sub0.time = sub1.time FULL OUTER JOIN " +
+			"(SELECT time_bucket($7::interval, \"time\", $8::text) AS \"time\", first(\"sensor.ENERGY.Total\", \"time\") AS value FROM " +
+			"\"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - $9::interval GROUP BY " +
+			"1 ORDER BY 1 ASC LIMIT 9) sub2 on sub0.time = sub2.time FULL OUTER JOIN (SELECT time_bucket($10::interval, \"time\", $11::text) AS" +
 			" \"time\", last(\"sensor.ENERGY.Total\", \"time\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\"" +
-			" WHERE \"time\" > now() - interval '8d' GROUP BY 1 ORDER BY 1 ASC LIMIT 9) sub3 on sub0.time = sub3.time FULL OUTER JOIN" +
-			" (SELECT time_bucket('1d', \"time\", 'Europe/Berlin') AS \"time\", avg(\"sensor.ENERGY.Total\") AS value FROM " +
-			"\"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - interval '8d' GROUP BY 1" +
-			" ORDER BY 1 ASC LIMIT 9) sub4 on sub0.time = sub4.time ORDER BY 1 DESC LIMIT 7"
+			" WHERE \"time\" > now() - $12::interval GROUP BY 1 ORDER BY 1 ASC LIMIT 9) sub3 on sub0.time = sub3.time FULL OUTER JOIN" +
+			" (SELECT time_bucket($13::interval, \"time\", $14::text) AS \"time\", avg(\"sensor.ENERGY.Total\") AS value FROM " +
+			"\"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - $15::interval GROUP BY 1" +
+			" ORDER BY 1 ASC LIMIT 9) sub4 on sub0.time = sub4.time ORDER BY 1 DESC LIMIT 7",
+			Args: []interface{}{"1d", "Europe/Berlin", "8d", "1d", "Europe/Berlin", "8d", "1d", "Europe/Berlin", "8d", "1d", "Europe/Berlin", "8d", "1d", "Europe/Berlin", "8d"}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
 
-		if actual[0] != expected {
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0])
 		}
 	})
@@ -272,24 +277,25 @@ func TestQueries(t *testing.T) {
 			OrderDirection:   &desc,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT sub0.time AS \"time\", " +
+		expected := Query{Sql: "SELECT sub0.time AS \"time\", " +
 			"(sub0.value) AS \"sensor.ENERGY.Total\", " +
 			"(sub1.value) +5 AS \"sensor.ENERGY.Total\" " +
-			"FROM (SELECT time_bucket('1d', \"time\", 'Europe/Berlin') AS \"time\", " +
+			"FROM (SELECT time_bucket($1::interval, \"time\", $2::text) AS \"time\", " +
 			"average(time_weight('Linear', \"time\", \"sensor.ENERGY.Total\")) AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
-			"WHERE \"time\" > now() - interval '10d' GROUP BY 1 ORDER BY 1 ASC) sub0 FULL OUTER JOIN " +
-			"(SELECT time_bucket('1d', \"time\", 'Europe/Berlin') AS \"time\", average(time_weight('LOCF', \"time\", \"sensor.ENERGY.Total\")) AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
-			"WHERE \"time\" > now() - interval '10d' GROUP BY 1 ORDER BY 1 ASC) sub1 on sub0.time = sub1.time " +
-			"ORDER BY 1 DESC"
+			"WHERE \"time\" > now() - $3::interval GROUP BY 1 ORDER BY 1 ASC) sub0 FULL OUTER JOIN " +
+			"(SELECT time_bucket($4::interval, \"time\", $5::text) AS \"time\", average(time_weight('LOCF', \"time\", \"sensor.ENERGY.Total\")) AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
+			"WHERE \"time\" > now() - $6::interval GROUP BY 1 ORDER BY 1 ASC) sub1 on sub0.time = sub1.time " +
+			"ORDER BY 1 DESC",
+			Args: []interface{}{"1d", "Europe/Berlin", "10d", "1d", "Europe/Berlin", "10d"}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
 
-		if actual[0] != expected {
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0])
 		}
 	})
@@ -307,18 +313,19 @@ func TestQueries(t *testing.T) {
 			OrderDirection:   &asc,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\"" +
+		expected := Query{Sql: "SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\"" +
 			" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE" +
-			" \"time\" > '2021-06-20T00:00:00Z' AND \"time\" < '2021-06-22T00:00:00Z' ORDER BY 1 ASC LIMIT 10"
+			" \"time\" > $1::timestamptz AND \"time\" < $2::timestamptz ORDER BY 1 ASC LIMIT 10",
+			Args: []interface{}{"2021-06-20T00:00:00Z", "2021-06-22T00:00:00Z"}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
 
-		if actual[0] != expected {
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n", expected, "\n", actual)
 		}
 	})
@@ -366,19 +373,21 @@ func TestQueries(t *testing.T) {
 				OrderColumnIndex: &zero,
 			}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{"", ""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{"", ""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 2 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := []string{"SELECT \"time\", \"sensor.ENERGY.Total\"+5 AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"+10" +
+		expected := []Query{{Sql: "SELECT \"time\", \"sensor.ENERGY.Total\"+5 AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"+10" +
 			" AS \"sensor.ENERGY.Total\" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE" +
-			" \"sensor.ENERGY.Total\"+5 > 10 AND \"time\" > now() - interval '1d' ORDER BY 1 ASC LIMIT 10",
-			"SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"" +
+			" \"sensor.ENERGY.Total\"+5 > $1::double precision AND \"time\" > now() - $2::interval ORDER BY 1 ASC LIMIT 10",
+			Args: []interface{}{ten, d1}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"},
+			{Sql: "SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"" +
 				" AS \"sensor.ENERGY.Total\" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE" +
-				" \"sensor.ENERGY.Total\"+5 > 10 AND \"time\" > now() - interval '1d' ORDER BY 1 ASC LIMIT 10",
+				" \"sensor.ENERGY.Total\"+5 > $1::double precision AND \"time\" > now() - $2::interval ORDER BY 1 ASC LIMIT 10",
+				Args: []interface{}{ten, d1}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"},
 		}
 
 		if !reflect.DeepEqual(actual, expected) {
@@ -411,19 +420,20 @@ func TestQueries(t *testing.T) {
 			OrderColumnIndex: &zero,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\"" +
+		expected := Query{Sql: "SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\"" +
 			" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\"" +
-			" WHERE \"sensor.Time_unit\"= 'iso_format' AND \"sensor.ENERGY.Total_unit\"!= 'invalid'" +
-			" AND \"time\" > now() - interval '1d' ORDER BY 1 ASC LIMIT 10"
+			" WHERE \"sensor.Time_unit\"= $1 AND \"sensor.ENERGY.Total_unit\"!= $2" +
+			" AND \"time\" > now() - $3::interval ORDER BY 1 ASC LIMIT 10",
+			Args: []interface{}{"iso_format", "invalid", "1d"}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
 
-		if actual[0] != expected {
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n", expected, "\n", actual)
 		}
 	})
@@ -448,18 +458,19 @@ func TestQueries(t *testing.T) {
 			OrderDirection:   &asc,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "ade1fba6-fa5f-4704-9997-81dc168f62f4", []string{"ade1fba6-fa5f-4704-9997-81dc168f62f4"}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "ade1fba6-fa5f-4704-9997-81dc168f62f4", []string{"ade1fba6-fa5f-4704-9997-81dc168f62f4"}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT \"time\", \"sensor.ENERGY.Total\"+5 AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"+10" +
+		expected := Query{Sql: "SELECT \"time\", \"sensor.ENERGY.Total\"+5 AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"+10" +
 			" AS \"sensor.ENERGY.Total\" FROM \"userid:reH7pvpfRwSZl4HcFo9i9A_export:l4BYIMoKRsWdzxbC44awUA\" WHERE" +
-			" \"sensor.ENERGY.Total\"+5 > 10 AND \"time\" > now() - interval '1d' ORDER BY 1 ASC LIMIT 10"
+			" \"sensor.ENERGY.Total\"+5 > $1::double precision AND \"time\" > now() - $2::interval ORDER BY 1 ASC LIMIT 10",
+			Args: []interface{}{ten, d1}, Table: "userid:reH7pvpfRwSZl4HcFo9i9A_export:l4BYIMoKRsWdzxbC44awUA"}
 
-		if actual[0] != expected {
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n", expected, "\n", actual)
 		}
 	})
@@ -486,18 +497,19 @@ func TestQueries(t *testing.T) {
 			OrderDirection:   &asc,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT \"time\", \"sensor.ENERGY.Total\"+5 AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"+10" +
+		expected := Query{Sql: "SELECT \"time\", \"sensor.ENERGY.Total\"+5 AS \"sensor.ENERGY.Total\", \"sensor.ENERGY.Total\"+10" +
 			" AS \"sensor.ENERGY.Total\" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE" +
-			" \"sensor.ENERGY.Total\"+5 > 10 AND \"time\" > now() AND \"time\" < now() + interval '1d' ORDER BY 1 ASC LIMIT 10"
+			" \"sensor.ENERGY.Total\"+5 > $1::double precision AND \"time\" > now() AND \"time\" < now() + $2::interval ORDER BY 1 ASC LIMIT 10",
+			Args: []interface{}{ten, d1}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
 
-		if actual[0] != expected {
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n", expected, "\n", actual)
 		}
 	})
@@ -517,17 +529,22 @@ func TestQueries(t *testing.T) {
 			GroupTime: &d1,
 		}
 
-		actual, err := getCAQuery(element, "table", "Europe/Berlin")
+		actual, args, err := getCAQuery(element, "table", "Europe/Berlin")
 		if err != nil {
 			t.Error(err)
 		}
-		expected := "SELECT view_name FROM (SELECT view_name, substring(view_definition, 'time_bucket\\((.*?)::interval, \"time\", ''Europe/Berlin''')::interval as bucket FROM timescaledb_information.continuous_aggregates WHERE hypertable_name = 'table' " +
-			"AND view_definition LIKE '%first(test1, \"time\") AS test1%'" +
-			"AND view_definition LIKE '%last(\"test.2\", \"time\") AS \"test.2\"%'" +
-			") sub WHERE bucket <= '1d'::interval ORDER BY bucket DESC LIMIT 1;"
+		expected := "SELECT view_name FROM (SELECT view_name, substring(view_definition, $1::text)::interval as bucket FROM timescaledb_information.continuous_aggregates WHERE hypertable_name = $2 " +
+			"AND view_definition LIKE $3 " +
+			"AND view_definition LIKE $4 " +
+			") sub WHERE bucket <= $5::interval ORDER BY bucket DESC LIMIT 1;"
 		if actual != expected {
 			t.Error("Expected/Actual\n", expected, "\n", actual)
 		}
+		expectedArgs := []interface{}{"time_bucket\\((.*?)::interval, \"time\", 'Europe/Berlin'", "table",
+			"%first(test1, \"time\") AS test1%", "%last(\"test.2\", \"time\") AS \"test.2\"%", d1}
+		if !reflect.DeepEqual(args, expectedArgs) {
+			t.Error("Expected/Actual\n", expectedArgs, "\n", args)
+		}
 	})
 
 	t.Run("Test GenerateQueries Long Field Name (Hashing)", func(t *testing.T) {
@@ -547,16 +564,17 @@ func TestQueries(t *testing.T) {
 			OrderDirection:   &desc,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\", \"2a697f637c7bc0af368f56d414fb6eb9c1d1026b1c7260b7baaf4da48e462c\" AS \"thisisatestforveryveryveryveryveryverylongfieldnameswhichneedtobehashed\" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - interval '7d' ORDER BY 1 DESC LIMIT 10"
+		expected := Query{Sql: "SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\", \"2a697f637c7bc0af368f56d414fb6eb9c1d1026b1c7260b7baaf4da48e462c\" AS \"thisisatestforveryveryveryveryveryverylongfieldnameswhichneedtobehashed\" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - $1::interval ORDER BY 1 DESC LIMIT 10",
+			Args: []interface{}{"7d"}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
 
-		if actual[0] != expected {
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0])
 		}
 	})
@@ -583,16 +601,17 @@ func TestQueries(t *testing.T) {
 			OrderDirection:   &desc,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\", \"2a697f637c7bc0af368f56d414fb6eb9c1d1026b1c7260b7baaf4da48e462c\" AS \"thisisatestforveryveryveryveryveryverylongfieldnameswhichneedtobehashed\" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"2a697f637c7bc0af368f56d414fb6eb9c1d1026b1c7260b7baaf4da48e462c\"> 5 AND \"time\" > now() - interval '7d' ORDER BY 1 DESC LIMIT 10"
+		expected := Query{Sql: "SELECT \"time\", \"sensor.ENERGY.Total\" AS \"sensor.ENERGY.Total\", \"2a697f637c7bc0af368f56d414fb6eb9c1d1026b1c7260b7baaf4da48e462c\" AS \"thisisatestforveryveryveryveryveryverylongfieldnameswhichneedtobehashed\" FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"2a697f637c7bc0af368f56d414fb6eb9c1d1026b1c7260b7baaf4da48e462c\"> $1::double precision AND \"time\" > now() - $2::interval ORDER BY 1 DESC LIMIT 10",
+			Args: []interface{}{5, "7d"}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
 
-		if actual[0] != expected {
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0])
 		}
 	})
@@ -618,23 +637,344 @@ func TestQueries(t *testing.T) {
 			OrderDirection:   &desc,
 		}}
 
-		actual, err := wrapper.GenerateQueries(elements, "", []string{""}, "", []models.Device{})
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
 		if err != nil {
 			t.Error(err)
 		}
 		if len(actual) != 1 {
 			t.Error("Unexpected number of queries", len(actual))
 		}
-		expected := "SELECT sub0.time AS \"time\", (sub0.value - lag(sub0.value) OVER (ORDER BY 1)) AS \"sensor.ENERGY.Total\"," +
+		expected := Query{Sql: "SELECT sub0.time AS \"time\", (sub0.value - lag(sub0.value) OVER (ORDER BY 1)) AS \"sensor.ENERGY.Total\"," +
 			" (sub1.value - lag(sub1.value) OVER (ORDER BY 1)) +5 AS \"thisisatestforveryveryveryveryveryverylongfieldnameswhichneedtobehashed\" " +
-			"FROM (SELECT time_bucket('1d', \"time\", 'Europe/Berlin') AS \"time\", last(\"sensor.ENERGY.Total\", \"time\") AS value FROM" +
-			" \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - interval '8d' GROUP BY " +
-			"1 ORDER BY 1 ASC LIMIT 9) sub0 FULL OUTER JOIN (SELECT time_bucket('1d', \"time\", 'Europe/Berlin') AS \"time\", " +
+			"FROM (SELECT time_bucket($1::interval, \"time\", $2::text) AS \"time\", last(\"sensor.ENERGY.Total\", \"time\") AS value FROM" +
+			" \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > now() - $3::interval GROUP BY " +
+			"1 ORDER BY 1 ASC LIMIT 9) sub0 FULL OUTER JOIN (SELECT time_bucket($4::interval, \"time\", $5::text) AS \"time\", " +
 			"first(\"2a697f637c7bc0af368f56d414fb6eb9c1d1026b1c7260b7baaf4da48e462c\", \"time\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
-			"WHERE \"time\" > now() - interval '8d' GROUP BY 1 ORDER BY 1 ASC LIMIT 9) sub1 on sub0.time = sub1.time ORDER BY 1 DESC LIMIT 7"
+			"WHERE \"time\" > now() - $6::interval GROUP BY 1 ORDER BY 1 ASC LIMIT 9) sub1 on sub0.time = sub1.time ORDER BY 1 DESC LIMIT 7",
+			Args: []interface{}{"1d", "Europe/Berlin", "8d", "1d", "Europe/Berlin", "8d"}, Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
+
+		if !reflect.DeepEqual(actual[0], expected) {
+			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0])
+		}
+	})
+	t.Run("Test GenerateQueries Fill", func(t *testing.T) {
+		h1 := "1h"
+		previous := model.FillPrevious
+		constant := "0"
+		elements := []model.QueriesRequestElement{{
+			DeviceId:  &deviceId,
+			ServiceId: &serviceId,
+			Time:      &timeFormTo,
+			Columns: []model.QueriesRequestElementColumn{
+				{
+					Name:      "sensor.ENERGY.Total",
+					GroupType: &mean,
+					Fill:      &previous,
+				},
+				{
+					Name:      "sensor.ENERGY.Total",
+					GroupType: &median,
+					Fill:      &constant,
+				}},
+			GroupTime:        &h1,
+			OrderColumnIndex: &zero,
+			OrderDirection:   &asc,
+		}}
+
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
+		if err != nil {
+			t.Error(err)
+		}
+		if len(actual) != 1 {
+			t.Error("Unexpected number of queries", len(actual))
+		}
+		expected := Query{Sql: "SELECT COALESCE(sub0.time, sub1.time) AS \"time\", " +
+			"(sub0.value) AS \"sensor.ENERGY.Total\", " +
+			"(sub1.value) AS \"sensor.ENERGY.Total\" " +
+			"FROM (SELECT time_bucket_gapfill($1::interval, \"time\", $2::text, $3::timestamptz, $4::timestamptz) AS \"time\", " +
+			"locf(avg(\"sensor.ENERGY.Total\")) AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
+			"WHERE \"time\" > $5::timestamptz AND \"time\" < $6::timestamptz GROUP BY 1 ORDER BY 1 ASC) sub0 FULL OUTER JOIN " +
+			"(SELECT time_bucket_gapfill($7::interval, \"time\", $8::text, $9::timestamptz, $10::timestamptz) AS \"time\", " +
+			"COALESCE(percentile_disc(0.5) WITHIN GROUP (ORDER BY \"sensor.ENERGY.Total\"), $11::double precision) AS value " +
+			"FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
+			"WHERE \"time\" > $12::timestamptz AND \"time\" < $13::timestamptz GROUP BY 1 ORDER BY 1 ASC) sub1 on sub0.time = sub1.time " +
+			"ORDER BY 1 ASC",
+			Args:  []interface{}{h1, "Europe/Berlin", start, end, start, end, h1, "Europe/Berlin", start, end, 0.0, start, end},
+			Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
+
+		if !reflect.DeepEqual(actual[0], expected) {
+			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0])
+		}
+	})
+	t.Run("Test GenerateQueries Fractional Fill", func(t *testing.T) {
+		h1 := "1h"
+		sum := "sum"
+		constant := "0.5"
+		// the sum of an integer column is numeric, a fraction bound with its type would fail to encode
+		elements := []model.QueriesRequestElement{{
+			DeviceId:  &deviceId,
+			ServiceId: &serviceId,
+			Time:      &timeFormTo,
+			Columns:   []model.QueriesRequestElementColumn{{Name: "sensor.ENERGY.Count", GroupType: &sum, Fill: &constant}},
+			GroupTime: &h1,
+		}}
+
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(actual[0].Sql, "COALESCE(sum(\"sensor.ENERGY.Count\"), $5::double precision)") || actual[0].Args[4] != 0.5 {
+			t.Error("expected fill bound as double precision", actual[0])
+		}
+	})
+	t.Run("Test GenerateQueries Stable Order", func(t *testing.T) {
+		limit := 10
+		elements := []model.QueriesRequestElement{{
+			DeviceId:         &deviceId,
+			ServiceId:        &serviceId,
+			Time:             &timeFormTo,
+			Columns:          []model.QueriesRequestElementColumn{{Name: "sensor.ENERGY.Total"}, {Name: "sensor.ENERGY.Count"}},
+			Limit:            &limit,
+			OrderColumnIndex: &zero,
+			OrderDirection:   &asc,
+			StableOrder:      true,
+		}}
+
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(actual[0].Sql, " ORDER BY 1 ASC, 2 ASC, 3 ASC LIMIT 10") {
+			t.Error("expected rows with the same time to be ordered by their values", actual[0].Sql)
+		}
+	})
+	t.Run("Test GenerateQueries Statistical Functions", func(t *testing.T) {
+		h1 := "1h"
+		p95 := "percentile-95"
+		p99 := "difference-percentile-approx-99.9"
+		countDistinct := "count-distinct"
+		skewness := "skewness"
+		elements := []model.QueriesRequestElement{{
+			DeviceId:  &deviceId,
+			ServiceId: &serviceId,
+			Time:      &time1d,
+			Columns: []model.QueriesRequestElementColumn{
+				{
+					Name:      "sensor.ENERGY.Total",
+					GroupType: &p95,
+				},
+				{
+					Name:      "sensor.ENERGY.Total",
+					GroupType: &p99,
+				},
+				{
+					Name:      "sensor.ENERGY.Total",
+					GroupType: &countDistinct,
+				},
+				{
+					Name:      "sensor.ENERGY.Total",
+					GroupType: &skewness,
+				}},
+			GroupTime:        &h1,
+			OrderColumnIndex: &zero,
+			OrderDirection:   &asc,
+		}}
+
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
+		if err != nil {
+			t.Error(err)
+		}
+		if len(actual) != 1 {
+			t.Error("Unexpected number of queries", len(actual))
+		}
+		table := "\"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\""
+		expected := "SELECT sub0.time AS \"time\", (sub0.value) AS \"sensor.ENERGY.Total\", " +
+			"(sub1.value - lag(sub1.value) OVER (ORDER BY 1)) AS \"sensor.ENERGY.Total\", " +
+			"(sub2.value) AS \"sensor.ENERGY.Total\", (sub3.value) AS \"sensor.ENERGY.Total\" " +
+			"FROM (SELECT time_bucket($1::interval, \"time\", $2::text) AS \"time\", " +
+			"percentile_disc(0.95) WITHIN GROUP (ORDER BY \"sensor.ENERGY.Total\") AS value FROM " + table + " " +
+			"WHERE \"time\" > now() - $3::interval GROUP BY 1 ORDER BY 1 ASC) sub0 FULL OUTER JOIN " +
+			"(SELECT time_bucket($4::interval, \"time\", $5::text) AS \"time\", " +
+			"approx_percentile(0.999, percentile_agg(\"sensor.ENERGY.Total\")) AS value FROM " + table + " " +
+			"WHERE \"time\" > now() - $6::interval GROUP BY 1 ORDER BY 1 ASC LIMIT 3) sub1 on sub0.time = sub1.time FULL OUTER JOIN " +
+			"(SELECT time_bucket($7::interval, \"time\", $8::text) AS \"time\", " +
+			"count(DISTINCT \"sensor.ENERGY.Total\") AS value FROM " + table + " " +
+			"WHERE \"time\" > now() - $9::interval GROUP BY 1 ORDER BY 1 ASC LIMIT 3) sub2 on sub0.time = sub2.time FULL OUTER JOIN " +
+			"(SELECT time_bucket($10::interval, \"time\", $11::text) AS \"time\", " +
+			"skewness(stats_agg(\"sensor.ENERGY.Total\")) AS value FROM " + table + " " +
+			"WHERE \"time\" > now() - $12::interval GROUP BY 1 ORDER BY 1 ASC LIMIT 3) sub3 on sub0.time = sub3.time " +
+			"ORDER BY 1 DESC LIMIT 1"
+		if actual[0].Sql != expected {
+			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0].Sql)
+		}
+	})
+
+	t.Run("Test CA Query Statistical Functions", func(t *testing.T) {
+		p95 := "percentile-95"
+		element := model.QueriesRequestElement{
+			Columns: []model.QueriesRequestElementColumn{{
+				Name:      "test1",
+				GroupType: &p95,
+			}},
+			GroupTime: &d1,
+		}
+		_, _, err := getCAQuery(element, "table", "Europe/Berlin")
+		if err == nil {
+			t.Error("expected percentiles not to be calculated from continuous aggregates")
+		}
+	})
+	t.Run("Test GenerateQueries Difference Counter Reset", func(t *testing.T) {
+		dmax := "difference-max"
+		counterMax := 99999.0
+		elements := []model.QueriesRequestElement{{
+			DeviceId:  &deviceId,
+			ServiceId: &serviceId,
+			Time:      &timeFormTo,
+			Columns: []model.QueriesRequestElementColumn{
+				{
+					Name:         "sensor.ENERGY.Total",
+					GroupType:    &dl,
+					CounterReset: true,
+				},
+				{
+					Name:       "sensor.ENERGY.Total",
+					GroupType:  &dmax,
+					CounterMax: &counterMax,
+				}},
+			GroupTime:        &d1,
+			OrderColumnIndex: &zero,
+			OrderDirection:   &desc,
+		}}
 
-		if actual[0] != expected {
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{""}, "", []models.Device{})
+		if err != nil {
+			t.Error(err)
+		}
+		if len(actual) != 1 {
+			t.Error("Unexpected number of queries", len(actual))
+		}
+		expected := Query{Sql: "SELECT sub0.time AS \"time\", " +
+			"(CASE WHEN sub0.value < lag(sub0.value) OVER (ORDER BY 1) THEN sub0.value " +
+			"ELSE sub0.value - lag(sub0.value) OVER (ORDER BY 1) END) AS \"sensor.ENERGY.Total\", " +
+			"(CASE WHEN sub1.value < lag(sub1.value) OVER (ORDER BY 1) THEN sub1.value + $1::double precision - lag(sub1.value) OVER (ORDER BY 1) " +
+			"ELSE sub1.value - lag(sub1.value) OVER (ORDER BY 1) END) AS \"sensor.ENERGY.Total\" " +
+			"FROM (SELECT time_bucket($2::interval, \"time\", $3::text) AS \"time\", last(\"sensor.ENERGY.Total\", \"time\") AS value FROM " +
+			"\"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" WHERE \"time\" > $4::timestamptz AND \"time\" < $5::timestamptz " +
+			"GROUP BY 1 ORDER BY 1 ASC LIMIT 4) sub0 FULL OUTER JOIN (SELECT time_bucket($6::interval, \"time\", $7::text) AS \"time\", " +
+			"max(\"sensor.ENERGY.Total\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
+			"WHERE \"time\" > $8::timestamptz AND \"time\" < $9::timestamptz GROUP BY 1 ORDER BY 1 ASC LIMIT 4) sub1 on sub0.time = sub1.time ORDER BY 1 DESC LIMIT 2",
+			Args:  []interface{}{counterMax, d1, "Europe/Berlin", "2021-06-19T00:00:00Z", end, d1, "Europe/Berlin", "2021-06-19T00:00:00Z", end},
+			Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
+
+		if !reflect.DeepEqual(actual[0], expected) {
 			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0])
 		}
 	})
+	t.Run("Test GenerateQueries Group Origin and Offset", func(t *testing.T) {
+		origin := "2021-01-15T00:00:00Z"
+		offset := "6h"
+		elements := []model.QueriesRequestElement{{
+			DeviceId:  &deviceId,
+			ServiceId: &serviceId,
+			Time:      &time10d,
+			Columns: []model.QueriesRequestElementColumn{
+				{
+					Name:      "sensor.ENERGY.Total",
+					GroupType: &mean,
+				}},
+			GroupTime:   &d1,
+			GroupOrigin: &origin,
+			GroupOffset: &offset,
+		}, {
+			DeviceId:  &deviceId,
+			ServiceId: &serviceId,
+			Time:      &timeFormTo,
+			Columns: []model.QueriesRequestElementColumn{
+				{
+					Name:      "sensor.ENERGY.Total",
+					GroupType: &dl,
+				}},
+			GroupTime:   &d1,
+			GroupOffset: &offset,
+		}}
+
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{"", ""}, "", []models.Device{})
+		if err != nil {
+			t.Error(err)
+		}
+		if len(actual) != 2 {
+			t.Error("Unexpected number of queries", len(actual))
+		}
+		expected := Query{Sql: "SELECT sub0.time AS \"time\", (sub0.value) AS \"sensor.ENERGY.Total\" " +
+			"FROM (SELECT time_bucket($1::interval, \"time\", $2::text, origin => $3::timestamptz, \"offset\" => $4::interval) AS \"time\", " +
+			"avg(\"sensor.ENERGY.Total\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
+			"WHERE \"time\" > now() - $5::interval GROUP BY 1 ORDER BY 1 ASC) sub0",
+			Args:  []interface{}{d1, "Europe/Berlin", origin, offset, d10},
+			Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
+		if !reflect.DeepEqual(actual[0], expected) {
+			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[0])
+		}
+		// the widened start is aligned to the bucket before the requested range
+		expected = Query{Sql: "SELECT sub0.time AS \"time\", (sub0.value - lag(sub0.value) OVER (ORDER BY 1)) AS \"sensor.ENERGY.Total\" " +
+			"FROM (SELECT time_bucket($1::interval, \"time\", $2::text, \"offset\" => $3::interval) AS \"time\", " +
+			"last(\"sensor.ENERGY.Total\", \"time\") AS value FROM \"device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA\" " +
+			"WHERE \"time\" > $4::timestamptz AND \"time\" < $5::timestamptz GROUP BY 1 ORDER BY 1 ASC LIMIT 4) sub0 ORDER BY 1 DESC LIMIT 2",
+			Args:  []interface{}{d1, "Europe/Berlin", offset, "2021-06-18T06:00:00+02:00", end},
+			Table: "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA"}
+		if !reflect.DeepEqual(actual[1], expected) {
+			t.Error("Expected/Actual\n\n", expected, "\n\n", actual[1])
+		}
+	})
+	t.Run("Test GenerateQueries V2 Group Origin and Offset", func(t *testing.T) {
+		origin := "2021-01-15T00:00:00Z"
+		offset := "6h"
+		group := "group"
+		column := model.QueriesRequestElementColumn{Name: "sensor.ENERGY.Total", GroupType: &mean}
+		// v2 queries each column on its own, device groups and locations are expanded to their devices and services
+		element := model.QueriesRequestElement{DeviceId: &deviceId, ServiceId: &serviceId, Time: &time10d, Columns: []model.QueriesRequestElementColumn{column},
+			GroupTime: &d1, GroupOrigin: &origin, GroupOffset: &offset}
+		groupElement := model.QueriesRequestElement{DeviceGroupId: &group, Time: &time10d, GroupTime: &d1, GroupOrigin: &origin, GroupOffset: &offset}
+		elements := []model.QueriesRequestElement{
+			element.ColumnElement(column, &[]model.QueriesRequestElementFilter{}),
+			groupElement.ExpandedElement(deviceId, serviceId, []model.QueriesRequestElementColumn{column}),
+		}
+		actual, err := wrapper.GenerateQueries(context.Background(), elements, "", []string{"", ""}, "", []models.Device{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range elements {
+			if !strings.Contains(actual[i].Sql, "origin => $3::timestamptz, \"offset\" => $4::interval") ||
+				!reflect.DeepEqual(actual[i].Args[2:4], []interface{}{origin, offset}) {
+				t.Error("expected origin and offset bind", actual[i])
+			}
+		}
+	})
+}
+
+func TestBindFilterValue(t *testing.T) {
+	args := []interface{}{}
+	for value, expected := range map[interface{}]string{
+		1.5:  "$1::double precision",
+		"on": "$1",
+		true: "$1",
+	} {
+		args = args[:0]
+		actual := bindFilterValue(&args, value)
+		if actual != expected || len(args) != 1 || args[0] != value {
+			t.Error("unexpected placeholder", value, actual, args)
+		}
+	}
+}
+
+func TestOrderedQuery(t *testing.T) {
+	limit := 10
+	query := Query{Sql: "SELECT \"time\", \"a\" FROM \"table\"", Args: []interface{}{}}
+	actual := OrderedQuery(query, 1, model.Desc, &limit)
+	if actual.Sql != "SELECT * FROM (SELECT \"time\", \"a\" FROM \"table\") AS unordered ORDER BY 2 DESC NULLS LAST LIMIT 10" {
+		t.Error("unexpected query", actual.Sql)
+	}
+	actual = OrderedQuery(query, 0, model.Asc, nil)
+	if actual.Sql != "SELECT * FROM (SELECT \"time\", \"a\" FROM \"table\") AS unordered ORDER BY 1 ASC NULLS FIRST" {
+		t.Error("unexpected query", actual.Sql)
+	}
 }
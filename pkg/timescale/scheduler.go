@@ -0,0 +1,153 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package timescale
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+)
+
+var ErrQueueTimeout = errors.Join(errors.New("timed out waiting for database"), model.ErrServiceUnavailable)
+
+type userKey struct{}
+
+// WithUser marks database work done with the returned context as work of the user, the scheduler queues it fairly among users.
+func WithUser(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userKey{}, userId)
+}
+
+// scheduler limits the number of database operations in flight. If all slots are taken, operations are queued per user
+// and slots are handed to the users in round robin order, so large requests of one user can't starve other users.
+type scheduler struct {
+	maxInFlight int
+	timeout     time.Duration
+	mux         sync.Mutex
+	inFlight    int
+	queues      map[string][]*ticket
+	users       []string // users with queued tickets in the order they are served
+}
+
+type ticket struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// newScheduler returns a scheduler allowing maxInFlight operations. Operations waiting longer than timeout fail with ErrQueueTimeout.
+// maxInFlight <= 0 disables the scheduler, timeout <= 0 lets operations wait until their context is done.
+func newScheduler(maxInFlight int, timeout time.Duration) *scheduler {
+	return &scheduler{maxInFlight: maxInFlight, timeout: timeout, queues: map[string][]*ticket{}}
+}
+
+// acquire waits for a slot. The returned release func has to be called when the operation is done.
+func (s *scheduler) acquire(ctx context.Context) (release func(), err error) {
+	if s == nil || s.maxInFlight <= 0 {
+		return func() {}, nil
+	}
+	userId, _ := ctx.Value(userKey{}).(string)
+	s.mux.Lock()
+	if s.inFlight < s.maxInFlight && len(s.users) == 0 {
+		s.inFlight++
+		s.mux.Unlock()
+		return s.releaseFunc(), nil
+	}
+	t := &ticket{ready: make(chan struct{})}
+	if len(s.queues[userId]) == 0 {
+		s.users = append(s.users, userId)
+	}
+	s.queues[userId] = append(s.queues[userId], t)
+	s.mux.Unlock()
+
+	var timeout <-chan time.Time
+	if s.timeout > 0 {
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-t.ready:
+		return s.releaseFunc(), nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrQueueTimeout
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if t.granted { // granted while giving up, pass the slot on
+		s.next()
+		return nil, err
+	}
+	s.remove(userId, t)
+	return nil, err
+}
+
+func (s *scheduler) releaseFunc() func() {
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			s.mux.Lock()
+			defer s.mux.Unlock()
+			s.next()
+		})
+	}
+}
+
+// next hands a freed slot to the next queued user. Has to be called with locked mux.
+func (s *scheduler) next() {
+	if len(s.users) == 0 {
+		s.inFlight--
+		return
+	}
+	userId := s.users[0]
+	s.users = s.users[1:]
+	queue := s.queues[userId]
+	t := queue[0]
+	if len(queue) > 1 {
+		s.queues[userId] = queue[1:]
+		s.users = append(s.users, userId)
+	} else {
+		delete(s.queues, userId)
+	}
+	t.granted = true
+	close(t.ready)
+}
+
+// remove drops a ticket that gave up waiting. Has to be called with locked mux.
+func (s *scheduler) remove(userId string, t *ticket) {
+	queue := s.queues[userId]
+	for i := range queue {
+		if queue[i] == t {
+			queue = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		s.queues[userId] = queue
+		return
+	}
+	delete(s.queues, userId)
+	for i := range s.users {
+		if s.users[i] == userId {
+			s.users = append(s.users[:i], s.users[i+1:]...)
+			break
+		}
+	}
+}
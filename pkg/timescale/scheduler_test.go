@@ -0,0 +1,126 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package timescale
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Run("fair", func(t *testing.T) {
+		s := newScheduler(1, 0)
+		release, err := s.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		order := make(chan string, 4)
+		queued := func() (n int) {
+			s.mux.Lock()
+			defer s.mux.Unlock()
+			for _, q := range s.queues {
+				n += len(q)
+			}
+			return n
+		}
+		queue := func(userId string) {
+			ctx := WithUser(context.Background(), userId)
+			before := queued()
+			go func() {
+				release, err := s.acquire(ctx)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				order <- userId
+				release()
+			}()
+			for queued() == before { // wait until queued, so the order is deterministic
+				time.Sleep(time.Millisecond)
+			}
+		}
+		queue("a")
+		queue("a")
+		queue("a")
+		queue("b")
+		release()
+		actual := []string{<-order, <-order, <-order, <-order}
+		expected := []string{"a", "b", "a", "a"}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Fatal("Expected/Actual\n", expected, "\n", actual)
+			}
+		}
+		release, err = s.acquire(context.Background()) // waits for the release of the last operation
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+		if s.inFlight != 0 {
+			t.Error("expected no operations in flight, got", s.inFlight)
+		}
+	})
+	t.Run("timeout", func(t *testing.T) {
+		s := newScheduler(1, 10*time.Millisecond)
+		release, err := s.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.acquire(context.Background())
+		if !errors.Is(err, ErrQueueTimeout) {
+			t.Fatal("expected ErrQueueTimeout, got", err)
+		}
+		if GetHTTPErrorCode(err) != http.StatusServiceUnavailable {
+			t.Error("expected 503, got", GetHTTPErrorCode(err))
+		}
+		release()
+		release() // releasing twice must not free another slot
+		if s.inFlight != 0 || len(s.users) != 0 {
+			t.Error("expected empty scheduler, got", s.inFlight, s.users)
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		s := newScheduler(1, 0)
+		release, err := s.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = s.acquire(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatal("expected context.Canceled, got", err)
+		}
+		release()
+		release, err = s.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	})
+	t.Run("disabled", func(t *testing.T) {
+		var s *scheduler
+		release, err := s.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	})
+}
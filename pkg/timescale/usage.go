@@ -17,6 +17,7 @@
 package timescale
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -25,7 +26,7 @@ import (
 	"github.com/jackc/pgx/pgtype"
 )
 
-func (wrapper *Wrapper) GetDeviceUsage(deviceIds []string) (res []model.Usage, err error) {
+func (wrapper *Wrapper) GetDeviceUsage(ctx context.Context, deviceIds []string) (res []model.Usage, err error) {
 	res = []model.Usage{}
 	if len(deviceIds) == 0 {
 		return res, err
@@ -39,7 +40,12 @@ func (wrapper *Wrapper) GetDeviceUsage(deviceIds []string) (res []model.Usage, e
 		shortDeviceIds = append(shortDeviceIds, "'"+shortId+"'")
 	}
 
-	rows, err := wrapper.pool.Query(fmt.Sprintf("SELECT substring(\"table\", 8, 22) as short_device_id, sum(bytes), min(updated_at), sum(bytes_per_day) FROM %v.usage WHERE substring(\"table\", 8, 22) IN (%v) GROUP BY short_device_id", wrapper.config.PostgresUsageSchema, strings.Join(shortDeviceIds, ", ")))
+	release, err := wrapper.scheduler.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	rows, err := wrapper.pool.QueryEx(ctx, fmt.Sprintf("SELECT substring(\"table\", 8, 22) as short_device_id, sum(bytes), min(updated_at), sum(bytes_per_day) FROM %v.usage WHERE substring(\"table\", 8, 22) IN (%v) GROUP BY short_device_id", wrapper.config.PostgresUsageSchema, strings.Join(shortDeviceIds, ", ")), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +69,7 @@ func (wrapper *Wrapper) GetDeviceUsage(deviceIds []string) (res []model.Usage, e
 	return res, nil
 }
 
-func (wrapper *Wrapper) GetExportUsage(exportIds []string) (res []model.Usage, err error) {
+func (wrapper *Wrapper) GetExportUsage(ctx context.Context, exportIds []string) (res []model.Usage, err error) {
 	res = []model.Usage{}
 	if len(exportIds) == 0 {
 		return res, err
@@ -77,7 +83,12 @@ func (wrapper *Wrapper) GetExportUsage(exportIds []string) (res []model.Usage, e
 		shortExportIds = append(shortExportIds, "'"+shortId+"'")
 	}
 
-	rows, err := wrapper.pool.Query(fmt.Sprintf("SELECT substring(\"table\", 38, 60) as short_export_id, bytes, updated_at, bytes_per_day FROM %v.usage WHERE substring(\"table\", 38, 60) IN (%v)", wrapper.config.PostgresUsageSchema, strings.Join(shortExportIds, ", ")))
+	release, err := wrapper.scheduler.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	rows, err := wrapper.pool.QueryEx(ctx, fmt.Sprintf("SELECT substring(\"table\", 38, 60) as short_export_id, bytes, updated_at, bytes_per_day FROM %v.usage WHERE substring(\"table\", 38, 60) IN (%v)", wrapper.config.PostgresUsageSchema, strings.Join(shortExportIds, ", ")), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -18,20 +18,38 @@ package timescale
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"sync"
 	"time"
 
 	serving "github.com/SENERGY-Platform/analytics-serving/client"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	importRepo "github.com/SENERGY-Platform/import-repository/lib/client"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
 	"github.com/jackc/pgx"
 )
 
-func NewWrapper(ctx context.Context, wg *sync.WaitGroup, config configuration.Config) (wrapper *Wrapper, err error) {
+// ErrNotConnected is returned by queries of a wrapper without database
+var ErrNotConnected = errors.New("not connected to database")
+
+// NewWrapper connects to the database. The pool has to be closed with Close.
+func NewWrapper(config configuration.Config) (wrapper *Wrapper, err error) {
 	servingClient := serving.New(config.ServingUrl)
 	importRepoClient := importRepo.NewClient(config.ImportRepoUrl)
+	maxConnections := int(config.PostgresMaxConnections)
+	if maxConnections <= 0 {
+		maxConnections = 5
+	}
+	acquireTimeout, err := parseOptionalDuration(config.PostgresAcquireTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres_acquire_timeout: %w", err)
+	}
+	queueTimeout, err := parseOptionalDuration(config.DbQueueTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid db_queue_timeout: %w", err)
+	}
 	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
 		ConnConfig: pgx.ConnConfig{
 			Host:     config.PostgresHost,
@@ -40,19 +58,45 @@ func NewWrapper(ctx context.Context, wg *sync.WaitGroup, config configuration.Co
 			User:     config.PostgresUser,
 			Password: config.PostgresPw,
 		},
-		MaxConnections: 5,
-		AcquireTimeout: 0,
+		MaxConnections: maxConnections,
+		AcquireTimeout: acquireTimeout,
 	})
 	if err != nil {
 		return nil, err
 	}
-	wg.Add(1)
-	go func() {
-		<-ctx.Done()
-		pool.Close()
-		wg.Done()
-	}()
-	return &Wrapper{config: config, pool: pool, servingClient: servingClient, importRepoClient: importRepoClient}, nil
+	err = metrics.RegisterPool(pool)
+	if err != nil {
+		log.Logger.Warn("could not register pool metrics", attributes.ErrorKey, err)
+	}
+	return &Wrapper{config: config, pool: pool, scheduler: newScheduler(int(config.DbMaxInFlight), queueTimeout), servingClient: servingClient, importRepoClient: importRepoClient}, nil
+}
+
+// NewWrapperWithoutDatabase returns a wrapper that generates queries, executing them fails with ErrNotConnected
+func NewWrapperWithoutDatabase(config configuration.Config) *Wrapper {
+	return &Wrapper{config: config, scheduler: newScheduler(int(config.DbMaxInFlight), 0), servingClient: serving.New(config.ServingUrl),
+		importRepoClient: importRepo.NewClient(config.ImportRepoUrl)}
+}
+
+// Close closes the pool, after all requests using it are done
+func (wrapper *Wrapper) Close() {
+	wrapper.pool.Close()
+}
+
+// Ping checks that a connection of the pool can execute queries. Bypasses the scheduler, so readiness doesn't depend on the load of users.
+func (wrapper *Wrapper) Ping(ctx context.Context) error {
+	if wrapper == nil || wrapper.pool == nil {
+		return ErrNotConnected
+	}
+	_, err := wrapper.pool.ExecEx(ctx, "SELECT 1", nil)
+	return err
+}
+
+// parseOptionalDuration parses durations like 10s, an empty string is no duration
+func parseOptionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
 }
 
 func (wrapper *Wrapper) Migrate() error {
@@ -0,0 +1,183 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package timescale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	util "github.com/SENERGY-Platform/timescale-tableworker/pkg/lib/handler"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxBindParameters is the limit of the postgres protocol, larger inserts are split into multiple statements
+const maxBindParameters = 65535
+
+var errMissingRowTime = errors.New("row does not start with a time")
+
+// WriteRows inserts the rows of request into the table of the device service or export. ownerUserId has to be the owner of an export.
+// With replace, rows with the same time are deleted first, so the write behaves like an upsert.
+// All statements run in a single transaction. Returns the number of inserted rows.
+func (wrapper *Wrapper) WriteRows(ctx context.Context, request model.WriteRequest, ownerUserId string, replace bool) (rowsAffected int64, err error) {
+	ctx, span := tracing.Start(ctx, "Wrapper.WriteRows", attribute.Int("rows", len(request.Rows)), attribute.Bool("replace", replace))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	table, err := hypertableName(request.ExportId, request.DeviceId, request.ServiceId, ownerUserId)
+	if err != nil {
+		return 0, err
+	}
+	inserts, times, err := generateInserts(table, request.Columns, request.Rows)
+	if err != nil {
+		return 0, err
+	}
+	queries := []Query{}
+	if replace {
+		queries = append(queries, generateDeleteTimes(table, times))
+	}
+	queries = append(queries, inserts...)
+	affected, err := wrapper.execInTransaction(ctx, queries)
+	if err != nil {
+		return 0, err
+	}
+	for _, n := range affected[len(queries)-len(inserts):] {
+		rowsAffected += n
+	}
+	return rowsAffected, nil
+}
+
+// DeleteRows deletes the rows of the device service or export in the time range of request. ownerUserId has to be the owner of an export.
+func (wrapper *Wrapper) DeleteRows(ctx context.Context, request model.DeleteRowsRequest, ownerUserId string) (rowsAffected int64, err error) {
+	ctx, span := tracing.Start(ctx, "Wrapper.DeleteRows")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	table, err := hypertableName(request.ExportId, request.DeviceId, request.ServiceId, ownerUserId)
+	if err != nil {
+		return 0, err
+	}
+	start, err := time.Parse(time.RFC3339, request.Start)
+	if err != nil {
+		return 0, err
+	}
+	end, err := time.Parse(time.RFC3339, request.End)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := wrapper.execInTransaction(ctx, []Query{generateDeleteRange(table, start, end)})
+	if err != nil {
+		return 0, err
+	}
+	return affected[0], nil
+}
+
+// execInTransaction executes all queries in one transaction and returns the number of affected rows of each query
+func (wrapper *Wrapper) execInTransaction(ctx context.Context, queries []Query) (affected []int64, err error) {
+	release, err := wrapper.scheduler.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	tx, err := wrapper.pool.BeginEx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // no-op after commit
+	for _, query := range queries {
+		if wrapper.config.Debug {
+			log.Logger.Debug("Exec", "query", query.Sql)
+		}
+		tag, err := tx.ExecEx(ctx, query.Sql, nil, query.Args...)
+		if err != nil {
+			return nil, err
+		}
+		affected = append(affected, tag.RowsAffected())
+	}
+	return affected, tx.CommitEx(ctx)
+}
+
+// generateInserts creates multi row inserts with hashed column names, split to respect maxBindParameters.
+// Also returns the parsed time of each row.
+func generateInserts(table string, columns []string, rows [][]interface{}) (queries []Query, times []time.Time, err error) {
+	columnList := "\"time\""
+	for _, column := range columns {
+		columnList += ", " + util.HashFieldNameIfNeeded(column)
+	}
+	rowsPerQuery := maxBindParameters / (len(columns) + 1)
+	for start := 0; start < len(rows); start += rowsPerQuery {
+		end := min(start+rowsPerQuery, len(rows))
+		args := []interface{}{}
+		values := []string{}
+		for _, row := range rows[start:end] {
+			t, err := parseRowTime(row)
+			if err != nil {
+				return nil, nil, err
+			}
+			times = append(times, t)
+			placeholders := []string{bind(&args, t)}
+			for _, value := range row[1:] {
+				number, ok := value.(json.Number)
+				if ok {
+					value = number.String() // converted by the column type, keeps integers exact
+				}
+				placeholders = append(placeholders, bind(&args, value))
+			}
+			values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		}
+		queries = append(queries, Query{
+			Sql:   "INSERT INTO \"" + table + "\" (" + columnList + ") VALUES " + strings.Join(values, ", "),
+			Args:  args,
+			Table: table,
+		})
+	}
+	return queries, times, nil
+}
+
+func generateDeleteTimes(table string, times []time.Time) Query {
+	args := []interface{}{}
+	return Query{
+		Sql:   "DELETE FROM \"" + table + "\" WHERE \"time\" = ANY(" + bind(&args, times) + ")",
+		Args:  args,
+		Table: table,
+	}
+}
+
+func generateDeleteRange(table string, start time.Time, end time.Time) Query {
+	args := []interface{}{}
+	return Query{
+		Sql:   "DELETE FROM \"" + table + "\" WHERE \"time\" >= " + bind(&args, start) + " AND \"time\" < " + bind(&args, end),
+		Args:  args,
+		Table: table,
+	}
+}
+
+func parseRowTime(row []interface{}) (time.Time, error) {
+	if len(row) == 0 {
+		return time.Time{}, errMissingRowTime
+	}
+	s, ok := row[0].(string)
+	if !ok {
+		return time.Time{}, errMissingRowTime
+	}
+	return time.Parse(time.RFC3339, s)
+}
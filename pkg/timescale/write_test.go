@@ -0,0 +1,127 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package timescale
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	deviceId := "urn:infai:ses:device:ade1fba6-fa5f-4704-9997-81dc168f62f4"
+	serviceId := "urn:infai:ses:service:97805820-ca0a-46c5-9dcf-16c2e386b050"
+	exportId := "e3a9d39f-d833-45df-81c0-e479d17c2e06"
+	userId := "d42d8d24-f2a2-4dd7-8ad3-4cabfb6f8062"
+	t1 := time.Date(2021, 6, 20, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2021, 6, 21, 0, 0, 0, 0, time.UTC)
+	t.Parallel()
+
+	t.Run("Test hypertableName", func(t *testing.T) {
+		actual, err := hypertableName(nil, &deviceId, &serviceId, userId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "device:reH7pvpfRwSZl4HcFo9i9A_service:l4BYIMoKRsWdzxbC44awUA" {
+			t.Error("Unexpected table", actual)
+		}
+		actual, err = hypertableName(&exportId, nil, nil, userId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "userid:1C2NJPKiTdeK00yr-2-AYg_export:46nTn9gzRd-BwOR50XwuBg" {
+			t.Error("Unexpected table", actual)
+		}
+	})
+
+	t.Run("Test generateInserts", func(t *testing.T) {
+		actual, times, err := generateInserts("table", []string{"sensor.ENERGY.Total", "thisisatestforveryveryveryveryveryverylongfieldnameswhichneedtobehashed"}, [][]interface{}{
+			{"2021-06-20T00:00:00Z", json.Number("12345678901234567"), "a"},
+			{"2021-06-21T00:00:00Z", nil, true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []Query{{
+			Sql:   "INSERT INTO \"table\" (\"time\", \"sensor.ENERGY.Total\", \"2a697f637c7bc0af368f56d414fb6eb9c1d1026b1c7260b7baaf4da48e462c\") VALUES ($1, $2, $3), ($4, $5, $6)",
+			Args:  []interface{}{t1, "12345678901234567", "a", t2, nil, true},
+			Table: "table",
+		}}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Error("Expected/Actual\n\n", expected, "\n\n", actual)
+		}
+		if !reflect.DeepEqual(times, []time.Time{t1, t2}) {
+			t.Error("Unexpected times", times)
+		}
+	})
+
+	t.Run("Test generateInserts splits large writes", func(t *testing.T) {
+		rows := [][]interface{}{}
+		for range maxBindParameters {
+			rows = append(rows, []interface{}{"2021-06-20T00:00:00Z", 1.0})
+		}
+		actual, _, err := generateInserts("table", []string{"value"}, rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(actual) != 3 {
+			t.Fatal("Unexpected number of queries", len(actual))
+		}
+		args := 0
+		for _, query := range actual {
+			if len(query.Args) > maxBindParameters {
+				t.Error("Too many args", len(query.Args))
+			}
+			args += len(query.Args)
+		}
+		if args != 2*maxBindParameters {
+			t.Error("Unexpected number of args", args)
+		}
+	})
+
+	t.Run("Test generateInserts invalid time", func(t *testing.T) {
+		_, _, err := generateInserts("table", []string{"value"}, [][]interface{}{{1.0, 1.0}})
+		if err == nil {
+			t.Error("Expected error")
+		}
+	})
+
+	t.Run("Test generateDeleteTimes and generateDeleteRange", func(t *testing.T) {
+		expected := Query{Sql: "DELETE FROM \"table\" WHERE \"time\" = ANY($1)", Args: []interface{}{[]time.Time{t1, t2}}, Table: "table"}
+		actual := generateDeleteTimes("table", []time.Time{t1, t2})
+		if !reflect.DeepEqual(actual, expected) {
+			t.Error("Expected/Actual\n\n", expected, "\n\n", actual)
+		}
+		expected = Query{Sql: "DELETE FROM \"table\" WHERE \"time\" >= $1 AND \"time\" < $2", Args: []interface{}{t1, t2}, Table: "table"}
+		actual = generateDeleteRange("table", t1, t2)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Error("Expected/Actual\n\n", expected, "\n\n", actual)
+		}
+	})
+	t.Run("Test generatePurgeEntirely", func(t *testing.T) {
+		expected := []Query{
+			{Sql: "DROP MATERIALIZED VIEW IF EXISTS \"table_1d\"", Table: "table"},
+			{Sql: "TRUNCATE \"table\"", Table: "table"},
+			{Sql: "REFRESH MATERIALIZED VIEW \"_wmv_table\"", Table: "table"},
+		}
+		actual := generatePurgeEntirely("table", []string{"table_1d"}, []string{"_wmv_table"})
+		if !reflect.DeepEqual(actual, expected) {
+			t.Error("Expected/Actual\n\n", expected, "\n\n", actual)
+		}
+	})
+}
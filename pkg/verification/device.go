@@ -17,11 +17,17 @@
 package verification
 
 import (
+	"context"
+
 	"github.com/SENERGY-Platform/permissions-v2/pkg/client"
 )
 
-func (verifier *Verifier) VerifyDevice(id string, token string) (result VerifierCacheEntry, err error) {
-	access, err, _ := verifier.permClient.CheckPermission(token, "devices", id, client.Execute)
+func (verifier *Verifier) VerifyDevice(ctx context.Context, id string, token string) (result VerifierCacheEntry, err error) {
+	return verifier.verifyDevice(ctx, id, token, client.Execute)
+}
+
+func (verifier *Verifier) verifyDevice(ctx context.Context, id string, token string, permission client.Permission) (result VerifierCacheEntry, err error) {
+	access, err := verifier.checkPermission(ctx, token, "devices", id, permission)
 	result.Ok = access
 	return result, err
 }
@@ -17,17 +17,36 @@
 package verification
 
 import (
+	"context"
+	"time"
+
+	serving "github.com/SENERGY-Platform/analytics-serving/client"
 	"github.com/SENERGY-Platform/permissions-v2/pkg/client"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const ServingExportInstanceTopic string = "export-instances"
 
-func (verifier *Verifier) VerifyExport(id string, token string, userId string) (result VerifierCacheEntry, err error) {
-	access, err, _ := verifier.permClient.CheckPermission(token, ServingExportInstanceTopic, id, client.Execute)
+func (verifier *Verifier) VerifyExport(ctx context.Context, id string, token string, userId string) (result VerifierCacheEntry, err error) {
+	return verifier.verifyExport(ctx, id, token, client.Execute)
+}
+
+func (verifier *Verifier) verifyExport(ctx context.Context, id string, token string, permission client.Permission) (result VerifierCacheEntry, err error) {
+	access, err := verifier.checkPermission(ctx, token, ServingExportInstanceTopic, id, permission)
 	if !access || err != nil {
 		return result, err
 	}
-	instance, err := verifier.servingClient.GetInstance(token, id)
+	_, span := tracing.Start(ctx, "serving.GetInstance", attribute.String("export_id", id))
+	instance, err := model.CallWithContext(ctx, func() (serving.Instance, error) {
+		start := time.Now()
+		instance, err := verifier.servingClient.GetInstance(token, id)
+		metrics.ObserveUpstream(metrics.Serving, start, err)
+		return instance, err
+	})
+	tracing.End(span, err)
 	if err != nil {
 		return result, err
 	}
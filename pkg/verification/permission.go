@@ -0,0 +1,60 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package verification
+
+import (
+	"context"
+
+	"github.com/SENERGY-Platform/permissions-v2/pkg/client"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
+)
+
+// VerifyWriteAccess checks write permission for an export or a device. Results are cached apart from read access.
+// OwnerUserId is set for exports, their table belongs to the owner.
+func (verifier *Verifier) VerifyWriteAccess(ctx context.Context, exportId *string, deviceId *string, token string, userId string) (result VerifierCacheEntry, err error) {
+	ctx, span := tracing.Start(ctx, "Verifier.VerifyWriteAccess")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return verifier.verifyPermission(ctx, exportId, deviceId, token, userId, client.Write)
+}
+
+// VerifyAdminAccess checks administrate permission for an export or a device, which only owners have.
+// OwnerUserId is set for exports, their table belongs to the owner.
+func (verifier *Verifier) VerifyAdminAccess(ctx context.Context, exportId *string, deviceId *string, token string, userId string) (result VerifierCacheEntry, err error) {
+	ctx, span := tracing.Start(ctx, "Verifier.VerifyAdminAccess")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return verifier.verifyPermission(ctx, exportId, deviceId, token, userId, client.Administrate)
+}
+
+// verifyPermission caches results per permission, read access is cached by VerifyAccessOnce
+func (verifier *Verifier) verifyPermission(ctx context.Context, exportId *string, deviceId *string, token string, userId string, permission client.Permission) (result VerifierCacheEntry, err error) {
+	if exportId != nil {
+		err = verifier.c.Use(string(permission)+":"+userId+*exportId, func() (interface{}, error) {
+			return verifier.verifyExport(ctx, *exportId, token, permission)
+		}, &result)
+		return
+	} else if deviceId != nil {
+		err = verifier.c.Use(string(permission)+":"+userId+*deviceId, func() (interface{}, error) {
+			return verifier.verifyDevice(ctx, *deviceId, token, permission)
+		}, &result)
+		return
+	}
+	return result, nil
+}
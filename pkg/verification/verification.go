@@ -17,16 +17,23 @@
 package verification
 
 import (
+	"context"
 	"errors"
 
 	serving "github.com/SENERGY-Platform/analytics-serving/client"
 	permClient "github.com/SENERGY-Platform/permissions-v2/pkg/client"
 
 	"sync"
+	"time"
 
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/cache"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/configuration"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/log"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/metrics"
 	"github.com/SENERGY-Platform/timescale-wrapper/pkg/model"
+	"github.com/SENERGY-Platform/timescale-wrapper/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Verifier struct {
@@ -44,17 +51,45 @@ type VerifierCacheEntry struct {
 func New(config configuration.Config) *Verifier {
 	permClient := permClient.New(config.PermissionsUrl)
 	servingClient := serving.New(config.ServingUrl)
+	localCache := cache.NewLocal()
+	if value, ok := config.CacheExpirations["permissions"]; ok {
+		expiration, err := time.ParseDuration(value)
+		if err != nil {
+			log.Logger.Warn("invalid permissions cache expiration, using default", attributes.ErrorKey, err)
+		} else {
+			localCache = cache.NewLocalWithExpiration(expiration)
+		}
+	}
 	return &Verifier{
-		c:             cache.NewLocal(),
+		c:             localCache,
 		config:        config,
 		permClient:    permClient,
 		servingClient: servingClient,
 	}
 }
 
+// Invalidate removes all cached results for a device, device group, location or export, e.g. after its permissions changed
+func (verifier *Verifier) Invalidate(id string) {
+	verifier.c.DeleteSuffix(id)
+}
+
 var errUnexpectedUpstreamStatuscode = errors.New("unexpected upstream statuscode")
 
-func (verifier *Verifier) VerifyAccess(elements []model.QueriesRequestElement, token string, userId string) (ok bool, userIds []string, err error) {
+// checkPermission stops waiting for the permissions service when ctx is done
+func (verifier *Verifier) checkPermission(ctx context.Context, token string, topicId string, id string, permissions ...permClient.Permission) (access bool, err error) {
+	_, span := tracing.Start(ctx, "permissions.CheckPermission", attribute.String("topic_id", topicId), attribute.String("id", id))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return model.CallWithContext(ctx, func() (bool, error) {
+		start := time.Now()
+		access, err, _ := verifier.permClient.CheckPermission(token, topicId, id, permissions...)
+		metrics.ObserveUpstream(metrics.Permissions, start, err)
+		return access, err
+	})
+}
+
+func (verifier *Verifier) VerifyAccess(ctx context.Context, elements []model.QueriesRequestElement, token string, userId string) (ok bool, userIds []string, err error) {
 	ok = true
 	userIds = make([]string, len(elements))
 	wg := &sync.WaitGroup{}
@@ -62,7 +97,7 @@ func (verifier *Verifier) VerifyAccess(elements []model.QueriesRequestElement, t
 		i := i // thread safe
 		wg.Add(1)
 		go func() {
-			result, errS := verifier.VerifyAccessOnce(elements[i], token, userId)
+			result, errS := verifier.VerifyAccessOnce(ctx, elements[i], token, userId)
 			if errS != nil {
 				err = errS
 				ok = false
@@ -78,25 +113,29 @@ func (verifier *Verifier) VerifyAccess(elements []model.QueriesRequestElement, t
 	return ok, userIds, err
 }
 
-func (verifier *Verifier) VerifyAccessOnce(element model.QueriesRequestElement, token string, userId string) (result VerifierCacheEntry, err error) {
+func (verifier *Verifier) VerifyAccessOnce(ctx context.Context, element model.QueriesRequestElement, token string, userId string) (result VerifierCacheEntry, err error) {
+	ctx, span := tracing.Start(ctx, "Verifier.VerifyAccessOnce")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	if element.ExportId != nil {
 		err = verifier.c.Use(userId+*element.ExportId, func() (interface{}, error) {
-			return verifier.VerifyExport(*element.ExportId, token, userId)
+			return verifier.VerifyExport(ctx, *element.ExportId, token, userId)
 		}, &result)
 		return
 	} else if element.DeviceId != nil {
 		err = verifier.c.Use(userId+*element.DeviceId, func() (interface{}, error) {
-			return verifier.VerifyDevice(*element.DeviceId, token)
+			return verifier.VerifyDevice(ctx, *element.DeviceId, token)
 		}, &result)
 		return
 	} else if element.DeviceGroupId != nil {
 		err = verifier.c.Use(userId+*element.DeviceGroupId, func() (interface{}, error) {
-			return verifier.VerifyDeviceGroup(*element.DeviceGroupId, token)
+			return verifier.VerifyDeviceGroup(ctx, *element.DeviceGroupId, token)
 		}, &result)
 		return
 	} else if element.LocationId != nil {
 		err = verifier.c.Use(userId+*element.LocationId, func() (interface{}, error) {
-			return verifier.VerifyLocation(*element.LocationId, token)
+			return verifier.VerifyLocation(ctx, *element.LocationId, token)
 		}, &result)
 		return
 	}